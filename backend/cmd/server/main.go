@@ -2,6 +2,8 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log"
 	"net/http"
 	"os"
@@ -13,35 +15,17 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/rs/cors"
 	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 
+	"blogapp/internals/auth"
 	"blogapp/internals/handlers"
 	"blogapp/internals/models"
+	"blogapp/internals/repository"
 )
 
-// Response structures to match your Angular app expectations
-type PostResponse struct {
-	ID          int       `json:"id"`
-	Title       string    `json:"title"`
-	Slug        string    `json:"slug"`
-	Excerpt     string    `json:"excerpt"`
-	Content     string    `json:"content"`
-	AuthorName  string    `json:"author_name"`
-	PublishedAt time.Time `json:"published_at"`
-	Category    string    `json:"category"`
-	Tags        []string  `json:"tags"`
-}
-
-type PostsListResponse struct {
-	Posts []PostResponse `json:"posts"`
-	Total int            `json:"total"`
-	Page  int            `json:"page"`
-	Limit int            `json:"limit"`
-}
-
-type SinglePostResponse struct {
-	Post PostResponse `json:"post"`
-}
+// schedulerInterval is how often scheduled posts are checked for going live
+const schedulerInterval = 30 * time.Second
 
 func main() {
 	// Load environment variables
@@ -49,42 +33,133 @@ func main() {
 		log.Println("No .env file found")
 	}
 
-	// Database connection
-	db, err := initDatabase()
+	// Markup allowed in post content; the built-in allowlist unless a file is given
+	if path := os.Getenv("CONTENT_ALLOWLIST"); path != "" {
+		allowlist, err := loadAllowlist(path)
+		if err != nil {
+			log.Fatal("Failed to load CONTENT_ALLOWLIST: ", err)
+		}
+		models.SetAllowlist(allowlist)
+	}
+
+	// Length of generated excerpts in characters
+	excerptLength, err := strconv.Atoi(getEnv("EXCERPT_LENGTH", strconv.Itoa(models.ExcerptLength)))
+	if err != nil || excerptLength < 1 || excerptLength > 480 {
+		log.Fatal("EXCERPT_LENGTH must be a whole number between 1 and 480")
+	}
+	models.ExcerptLength = excerptLength
+
+	// Data source: postgres (default), sqlite or mock fixtures
+	db, postRepo, err := initPostRepository(getEnv("DATA_SOURCE", "postgres"))
 	if err != nil {
-		log.Println("Database connection failed, will use mock data:", err)
-		db = nil
+		log.Fatal("Failed to initialize data source: ", err)
 	}
 
-	var blogHandler *handlers.BlogHandler
-	if db != nil {
-		// Auto migrate the schema
-		if err := db.AutoMigrate(&models.BlogPost{}); err != nil {
-			log.Println("Failed to migrate database:", err)
-		}
-		// Initialize handlers
-		blogHandler = handlers.NewBlogHandler(db)
+	// Initialize handlers
+	blogHandler := handlers.NewBlogHandler(db, postRepo)
+
+	// Feeds link back to the site, so they need its public address
+	feedContent := getEnv("FEED_CONTENT", "full")
+	if feedContent != "full" && feedContent != "excerpt" {
+		log.Fatal("FEED_CONTENT must be full or excerpt")
+	}
+	feedSize, err := strconv.Atoi(getEnv("FEED_SIZE", "20"))
+	if err != nil || feedSize < 1 || feedSize > 100 {
+		log.Fatal("FEED_SIZE must be a whole number between 1 and 100")
+	}
+	siteTitle := getEnv("SITE_TITLE", "The Accessibility Blog")
+	blogHandler.Feeds = handlers.FeedSettings{
+		Title:       siteTitle,
+		Description: getEnv("SITE_DESCRIPTION", "The latest posts from "+siteTitle),
+		SiteURL:     strings.TrimRight(getEnv("SITE_URL", "http://localhost:8080"), "/"),
+		FullContent: feedContent == "full",
+		Size:        feedSize,
 	}
 
 	// Setup routes
 	router := mux.NewRouter()
 
-	// API routes with mock data fallback
 	api := router.PathPrefix("/api").Subrouter()
+	api.NotFoundHandler = handlers.UnmatchedHandler(api)
+	api.MethodNotAllowedHandler = api.NotFoundHandler
 
 	// Posts endpoints
-	api.HandleFunc("/posts", func(w http.ResponseWriter, r *http.Request) {
-		handleGetPosts(w, r, blogHandler)
-	}).Methods("GET")
+	api.HandleFunc("/posts", blogHandler.GetPosts).Methods("GET")
+	api.HandleFunc("/posts/{slug}", blogHandler.GetPostBySlug).Methods("GET")
+	api.HandleFunc("/search", blogHandler.SearchPosts).Methods("GET")
+	api.HandleFunc("/tags", blogHandler.GetTags).Methods("GET")
+	api.HandleFunc("/tags/{tag}/posts", blogHandler.GetTagPosts).Methods("GET")
+	api.HandleFunc("/categories", blogHandler.GetCategories).Methods("GET")
+	api.HandleFunc("/categories/{slug}", blogHandler.GetCategory).Methods("GET")
+
+	// Write endpoints need a real database and are protected by JWT auth
+	if db != nil {
+		tokens, err := auth.NewTokenService(os.Getenv("JWT_SECRET"))
+		if err != nil {
+			log.Fatal("Failed to initialize auth: ", err)
+		}
+		tokens.Unauthorized = handlers.Unauthorized
+		blogHandler.Previews = tokens
+		blogHandler.RequireApproval = getEnv("REQUIRE_REVIEW_APPROVAL", "false") == "true"
 
-	api.HandleFunc("/posts/{slug}", func(w http.ResponseWriter, r *http.Request) {
-		handleGetPost(w, r, blogHandler)
-	}).Methods("GET")
+		if err := seedAdminUser(db); err != nil {
+			log.Fatal("Failed to seed admin user: ", err)
+		}
 
-	if blogHandler != nil {
-		api.HandleFunc("/posts", blogHandler.CreatePost).Methods("POST")
-		api.HandleFunc("/posts/{slug}", blogHandler.UpdatePost).Methods("PUT")
-		api.HandleFunc("/posts/{slug}", blogHandler.DeletePost).Methods("DELETE")
+		authHandler := handlers.NewAuthHandler(db, tokens)
+		api.HandleFunc("/auth/login", authHandler.Login).Methods("POST")
+		api.HandleFunc("/auth/refresh", authHandler.Refresh).Methods("POST")
+		api.HandleFunc("/auth/logout", authHandler.Logout).Methods("POST")
+
+		api.HandleFunc("/posts", tokens.RequireAuth(blogHandler.CreatePost)).Methods("POST")
+		api.HandleFunc("/posts/{slug}", tokens.RequireAuth(blogHandler.UpdatePost)).Methods("PUT")
+		api.HandleFunc("/posts/{slug}", tokens.RequireAuth(blogHandler.PatchPost)).Methods("PATCH")
+		api.HandleFunc("/posts/{slug}", tokens.RequireAuth(blogHandler.DeletePost)).Methods("DELETE")
+		api.HandleFunc("/posts/{slug}/restore", tokens.RequireAuth(blogHandler.RestorePost)).Methods("POST")
+		api.HandleFunc("/posts/{slug}/preview", tokens.RequireAuth(blogHandler.CreatePreview)).Methods("POST")
+
+		// Reader comments are public; moderation is under /admin
+		api.HandleFunc("/posts/{slug}/comments", blogHandler.GetComments).Methods("GET")
+		api.HandleFunc("/posts/{slug}/comments", blogHandler.CreateComment).Methods("POST")
+
+		// Editorial review
+		api.HandleFunc("/reviews", tokens.RequireAuth(blogHandler.GetMyReviews)).Methods("GET")
+		api.HandleFunc("/posts/{slug}/reviews", tokens.RequireAuth(blogHandler.ListReviews)).Methods("GET")
+		api.HandleFunc("/posts/{slug}/reviews", tokens.RequireAuth(blogHandler.RequestReview)).Methods("POST")
+		api.HandleFunc("/posts/{slug}/reviews/{id:[0-9]+}/reviewer", tokens.RequireAuth(blogHandler.AssignReviewer)).Methods("PUT")
+		api.HandleFunc("/posts/{slug}/reviews/{id:[0-9]+}/approve", tokens.RequireAuth(blogHandler.ApproveReview)).Methods("POST")
+		api.HandleFunc("/posts/{slug}/reviews/{id:[0-9]+}/request-changes", tokens.RequireAuth(blogHandler.RequestChanges)).Methods("POST")
+		api.HandleFunc("/posts/{slug}/reviews/{id:[0-9]+}/comments", tokens.RequireAuth(blogHandler.AddReviewComment)).Methods("POST")
+		api.HandleFunc("/posts/{slug}/revisions", tokens.RequireAuth(blogHandler.ListRevisions)).Methods("GET")
+		api.HandleFunc("/posts/{slug}/revisions/{id:[0-9]+}", tokens.RequireAuth(blogHandler.GetRevision)).Methods("GET")
+		api.HandleFunc("/posts/{slug}/revisions/{id:[0-9]+}/diff", tokens.RequireAuth(blogHandler.DiffRevision)).Methods("GET")
+		api.HandleFunc("/posts/{slug}/revisions/{id:[0-9]+}/restore", tokens.RequireAuth(blogHandler.RestoreRevision)).Methods("POST")
+
+		categoryHandler := handlers.NewCategoryHandler(db)
+		api.HandleFunc("/categories", tokens.RequireAuth(categoryHandler.CreateCategory)).Methods("POST")
+		api.HandleFunc("/categories/{id:[0-9]+}", tokens.RequireAuth(categoryHandler.UpdateCategory)).Methods("PUT")
+		api.HandleFunc("/categories/{id:[0-9]+}", tokens.RequireAuth(categoryHandler.DeleteCategory)).Methods("DELETE")
+
+		// Admin endpoints
+		userHandler := handlers.NewUserHandler(db)
+		api.HandleFunc("/admin/users", tokens.RequireAuth(userHandler.ListUsers)).Methods("GET")
+		api.HandleFunc("/admin/users", tokens.RequireAuth(userHandler.CreateUser)).Methods("POST")
+		api.HandleFunc("/admin/users/{id:[0-9]+}", tokens.RequireAuth(userHandler.UpdateUser)).Methods("PUT")
+		api.HandleFunc("/admin/users/{id:[0-9]+}", tokens.RequireAuth(userHandler.DeleteUser)).Methods("DELETE")
+		api.HandleFunc("/admin/trash", tokens.RequireAuth(blogHandler.GetTrash)).Methods("GET")
+		api.HandleFunc("/admin/comments", tokens.RequireAuth(blogHandler.GetModerationQueue)).Methods("GET")
+		api.HandleFunc("/admin/comments/{id:[0-9]+}/approve", tokens.RequireAuth(blogHandler.ApproveComment)).Methods("POST")
+		api.HandleFunc("/admin/comments/{id:[0-9]+}/reject", tokens.RequireAuth(blogHandler.RejectComment)).Methods("POST")
+		api.HandleFunc("/admin/comments/{id:[0-9]+}/spam", tokens.RequireAuth(blogHandler.MarkCommentSpam)).Methods("POST")
+
+		retentionDays, err := strconv.Atoi(getEnv("TRASH_RETENTION_DAYS", "30"))
+		if err != nil || retentionDays < 0 {
+			log.Fatal("TRASH_RETENTION_DAYS must be a whole number of days")
+		}
+		if retentionDays > 0 {
+			go purgeTrash(db, time.Duration(retentionDays)*24*time.Hour)
+		}
+		go publishScheduled(db, blogHandler.RequireApproval)
 	}
 
 	// Health check
@@ -94,6 +169,11 @@ func main() {
 		json.NewEncoder(w).Encode(map[string]string{"status": "OK"})
 	}).Methods("GET")
 
+	// RSS 2.0 and Atom feeds of published posts
+	router.HandleFunc("/{format:feed|atom}.xml", blogHandler.GetFeed).Methods("GET")
+	router.HandleFunc("/category/{slug}/{format:feed|atom}.xml", blogHandler.GetCategoryFeed).Methods("GET")
+	router.HandleFunc("/tag/{tag}/{format:feed|atom}.xml", blogHandler.GetTagFeed).Methods("GET")
+
 	// Serve static files from frontend directory
 	staticFileHandler := http.FileServer(http.Dir("./frontend/"))
 
@@ -105,7 +185,7 @@ func main() {
 	router.PathPrefix("/").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Don't serve index.html for API routes
 		if strings.HasPrefix(r.URL.Path, "/api/") {
-			http.NotFound(w, r)
+			handlers.NotFound(w, r)
 			return
 		}
 
@@ -123,12 +203,13 @@ func main() {
 	// CORS configuration
 	c := cors.New(cors.Options{
 		AllowedOrigins:   []string{"http://localhost:3000", "http://localhost:8000", "http://localhost:8080", "http://localhost:3001"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
+		ExposedHeaders:   []string{handlers.RequestIDHeader, "ETag", "Last-Modified"},
 		AllowCredentials: true,
 	})
 
-	handler := c.Handler(router)
+	handler := c.Handler(handlers.RequestID(router))
 
 	// Get port from environment or default to 8080
 	port := os.Getenv("PORT")
@@ -142,197 +223,133 @@ func main() {
 	log.Fatal(http.ListenAndServe(":"+port, handler))
 }
 
-func handleGetPosts(w http.ResponseWriter, r *http.Request, blogHandler *handlers.BlogHandler) {
-	w.Header().Set("Content-Type", "application/json")
-
-	// Parse query parameters
-	page := 1
-	limit := 6
-	searchQuery := r.URL.Query().Get("search")
-
-	if p := r.URL.Query().Get("page"); p != "" {
-		if parsed, err := strconv.Atoi(p); err == nil && parsed > 0 {
-			page = parsed
+// initPostRepository builds the post repository for the configured data source.
+// The fixture repository is only used when explicitly requested with DATA_SOURCE=mock;
+// a failing database connection is an error rather than a reason to serve mock data.
+func initPostRepository(dataSource string) (*gorm.DB, repository.PostRepository, error) {
+	switch dataSource {
+	case "mock":
+		log.Println("DATA_SOURCE=mock: serving fixture posts, write endpoints are disabled")
+		log.Println("Search backend: substring matching")
+		return nil, repository.NewMemoryPostRepository(repository.FixturePosts()), nil
+	case "postgres", "sqlite":
+		db, err := initDatabase(dataSource)
+		if err != nil {
+			return nil, nil, fmt.Errorf("database connection failed: %w", err)
 		}
-	}
 
-	if l := r.URL.Query().Get("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
-			limit = parsed
+		// Auto migrate the schema
+		if err := db.SetupJoinTable(&models.BlogPost{}, "Tags", &models.PostTag{}); err != nil {
+			return nil, nil, fmt.Errorf("failed to set up post_tags: %w", err)
+		}
+		if err := db.AutoMigrate(&models.BlogPost{}, &models.User{}, &models.RefreshToken{}, &models.SlugHistory{}, &models.Tag{}, &models.PostTag{}, &models.Category{}, &models.PostRevision{}, &models.Review{}, &models.ReviewComment{}, &models.Comment{}); err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate database: %w", err)
 		}
-	}
-
-	// Try to get posts from database first
-	if blogHandler != nil {
-		// If you have a working database, you can try to use the real handler
-		// For now, we'll fall back to mock data
-	}
-
-	// Use mock data
-	mockPosts := getMockPosts()
-
-	// Filter by search query if provided
-	if searchQuery != "" {
-		mockPosts = filterPostsBySearch(mockPosts, searchQuery)
-	}
 
-	// Apply pagination
-	total := len(mockPosts)
-	start := (page - 1) * limit
-	end := start + limit
+		if err := repository.MigrateTags(db); err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate tags: %w", err)
+		}
+		if err := repository.MigrateCategories(db); err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate categories: %w", err)
+		}
+		if err := repository.MigrateStatuses(db); err != nil {
+			return nil, nil, fmt.Errorf("failed to migrate post statuses: %w", err)
+		}
+		if err := repository.MigrateContent(db); err != nil {
+			return nil, nil, fmt.Errorf("failed to render post content: %w", err)
+		}
 
-	if start >= total {
-		mockPosts = []PostResponse{}
-	} else {
-		if end > total {
-			end = total
+		if err := repository.EnsureSearchIndex(db); err != nil {
+			log.Println("Full-text search index unavailable, searches will use substring matching:", err)
 		}
-		mockPosts = mockPosts[start:end]
-	}
 
-	response := PostsListResponse{
-		Posts: mockPosts,
-		Total: total,
-		Page:  page,
-		Limit: limit,
+		posts := repository.NewGormPostRepository(db)
+		log.Println("Search backend:", posts.SearchBackend())
+		return db, posts, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown DATA_SOURCE %q (expected postgres, sqlite or mock)", dataSource)
 	}
-
-	json.NewEncoder(w).Encode(response)
 }
 
-func handleGetPost(w http.ResponseWriter, r *http.Request, blogHandler *handlers.BlogHandler) {
-	w.Header().Set("Content-Type", "application/json")
-
-	vars := mux.Vars(r)
-	slug := vars["slug"]
-
-	// Try to get post from database first
-	if blogHandler != nil {
-		// If you have a working database, you can try to use the real handler
-		// For now, we'll fall back to mock data
-	}
-
-	// Use mock data
-	mockPosts := getMockPosts()
-
-	for _, post := range mockPosts {
-		if post.Slug == slug {
-			response := SinglePostResponse{Post: post}
-			json.NewEncoder(w).Encode(response)
-			return
+// publishScheduled flips scheduled posts live once their publish date has
+// passed. Public queries already hide posts dated in the future, so a post is
+// never visible early, only up to schedulerInterval late. With requireApproval
+// set, posts without an approval of their current version are held back.
+func publishScheduled(db *gorm.DB, requireApproval bool) {
+	ticker := time.NewTicker(schedulerInterval)
+	defer ticker.Stop()
+
+	for {
+		published, err := repository.PublishDue(db, time.Now(), requireApproval)
+		if err != nil {
+			log.Println("Failed to publish scheduled posts:", err)
+		} else if published > 0 {
+			log.Printf("Published %d scheduled posts", published)
 		}
+		<-ticker.C
 	}
-
-	// Post not found
-	w.WriteHeader(http.StatusNotFound)
-	json.NewEncoder(w).Encode(map[string]string{"error": "Post not found"})
 }
 
-func getMockPosts() []PostResponse {
-	return []PostResponse{
-		{
-			ID:          1,
-			Title:       "Getting Started with Web Accessibility",
-			Slug:        "getting-started-web-accessibility",
-			Excerpt:     "Learn the fundamentals of web accessibility and why it's crucial for creating inclusive digital experiences for all users.",
-			Content:     "<h2>Introduction to Web Accessibility</h2><p>Web accessibility is about making your website usable by everyone, including people with disabilities. This includes visual, auditory, physical, speech, cognitive, and neurological disabilities.</p><h3>Why Accessibility Matters</h3><p>Accessibility ensures that people with disabilities can perceive, understand, navigate, and interact with your website effectively. It's not just the right thing to do—it's often legally required and makes business sense.</p><h3>Getting Started</h3><p>Start by learning the Web Content Accessibility Guidelines (WCAG) 2.1. These guidelines provide a framework for making web content more accessible to people with disabilities.</p><p>Focus on the four main principles:</p><ul><li><strong>Perceivable</strong> - Information must be presentable in ways users can perceive</li><li><strong>Operable</strong> - Interface components must be operable</li><li><strong>Understandable</strong> - Information and UI operation must be understandable</li><li><strong>Robust</strong> - Content must be robust enough for interpretation by assistive technologies</li></ul>",
-			AuthorName:  "Sarah Johnson",
-			PublishedAt: time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC),
-			Category:    "Accessibility",
-			Tags:        []string{"accessibility", "web development", "inclusive design", "WCAG"},
-		},
-		{
-			ID:          2,
-			Title:       "ARIA Labels: A Complete Guide",
-			Slug:        "aria-labels-complete-guide",
-			Excerpt:     "Master the use of ARIA labels to improve screen reader compatibility and enhance the accessibility of your web applications.",
-			Content:     "<h2>Understanding ARIA Labels</h2><p>ARIA (Accessible Rich Internet Applications) labels provide additional context to assistive technologies like screen readers. They help users understand the purpose and state of interactive elements.</p><h3>Common ARIA Labels</h3><p>The most commonly used ARIA labels include:</p><ul><li><strong>aria-label</strong> - Provides an accessible name for an element</li><li><strong>aria-labelledby</strong> - References other elements that describe the current element</li><li><strong>aria-describedby</strong> - References elements that provide additional description</li></ul><h3>Best Practices</h3><p>Always test your ARIA labels with actual screen readers. What makes sense visually might not work well for assistive technology users.</p><p>Remember that ARIA labels should supplement, not replace, semantic HTML elements.</p>",
-			AuthorName:  "Michael Chen",
-			PublishedAt: time.Date(2024, 1, 12, 14, 30, 0, 0, time.UTC),
-			Category:    "Technical",
-			Tags:        []string{"ARIA", "screen readers", "accessibility", "labels"},
-		},
-		{
-			ID:          3,
-			Title:       "Color Contrast in Design",
-			Slug:        "color-contrast-design",
-			Excerpt:     "Understanding color contrast ratios and how to ensure your designs meet accessibility standards for users with visual impairments.",
-			Content:     "<h2>The Importance of Color Contrast</h2><p>Color contrast is crucial for readability. The Web Content Accessibility Guidelines (WCAG) specify minimum contrast ratios that must be met for text and background colors.</p><h3>WCAG Standards</h3><p>WCAG 2.1 requires:</p><ul><li><strong>Level AA</strong> - 4.5:1 contrast ratio for normal text, 3:1 for large text</li><li><strong>Level AAA</strong> - 7:1 contrast ratio for normal text, 4.5:1 for large text</li></ul><h3>Testing Tools</h3><p>Use tools like WebAIM's Color Contrast Checker or browser extensions to verify your color combinations meet accessibility standards.</p><h3>Beyond Compliance</h3><p>Good color contrast benefits everyone, not just users with visual impairments. It improves readability in bright sunlight, on older monitors, and for users with temporary vision issues.</p>",
-			AuthorName:  "Emily Rodriguez",
-			PublishedAt: time.Date(2024, 1, 8, 9, 15, 0, 0, time.UTC),
-			Category:    "Design",
-			Tags:        []string{"color", "contrast", "visual design", "WCAG", "testing"},
-		},
-		{
-			ID:          4,
-			Title:       "Keyboard Navigation Best Practices",
-			Slug:        "keyboard-navigation-best-practices",
-			Excerpt:     "Learn how to implement proper keyboard navigation patterns to ensure your website is accessible to users who cannot use a mouse.",
-			Content:     "<h2>Keyboard Navigation Fundamentals</h2><p>Keyboard navigation is essential for users with motor disabilities and those who prefer keyboard shortcuts. Proper focus management and logical tab order are critical.</p><h3>Tab Order</h3><p>Ensure your tab order follows a logical sequence that matches the visual layout of your page. Use the tabindex attribute sparingly and preferably with semantic HTML elements.</p><h3>Focus Indicators</h3><p>Always provide visible focus indicators so users can see which element currently has keyboard focus. Never remove focus outlines without providing an alternative.</p><h3>Skip Links</h3><p>Provide skip links to help keyboard users navigate quickly to main content areas, bypassing repetitive navigation elements.</p>",
-			AuthorName:  "David Kim",
-			PublishedAt: time.Date(2024, 1, 5, 16, 45, 0, 0, time.UTC),
-			Category:    "Development",
-			Tags:        []string{"keyboard", "navigation", "focus management", "usability"},
-		},
-		{
-			ID:          5,
-			Title:       "Screen Reader Testing Guide",
-			Slug:        "screen-reader-testing-guide",
-			Excerpt:     "A comprehensive guide to testing your websites with popular screen readers like NVDA, JAWS, and VoiceOver.",
-			Content:     "<h2>Why Test with Screen Readers?</h2><p>Testing with screen readers is crucial for understanding how blind and visually impaired users experience your website. This guide covers the most popular screen readers and testing techniques.</p><h3>Popular Screen Readers</h3><ul><li><strong>NVDA</strong> - Free and open-source, popular on Windows</li><li><strong>JAWS</strong> - Commercial screen reader, widely used in professional settings</li><li><strong>VoiceOver</strong> - Built into macOS and iOS</li><li><strong>TalkBack</strong> - Android's built-in screen reader</li></ul><h3>Testing Strategies</h3><p>Start by navigating your site with your eyes closed, using only the keyboard and screen reader. Pay attention to how information is announced and whether the navigation makes sense.</p>",
-			AuthorName:  "Lisa Thompson",
-			PublishedAt: time.Date(2024, 1, 2, 11, 20, 0, 0, time.UTC),
-			Category:    "Testing",
-			Tags:        []string{"screen readers", "testing", "NVDA", "JAWS", "VoiceOver"},
-		},
-		{
-			ID:          6,
-			Title:       "Accessible Form Design",
-			Slug:        "accessible-form-design",
-			Excerpt:     "Design forms that are usable by everyone with proper labeling, error handling, and validation techniques.",
-			Content:     "<h2>Forms and Accessibility</h2><p>Forms are critical interaction points on websites. Accessible forms must have proper labels, clear error messages, and logical grouping to be usable by assistive technologies.</p><h3>Essential Elements</h3><ul><li><strong>Labels</strong> - Every form control needs a proper label</li><li><strong>Fieldsets</strong> - Group related form controls logically</li><li><strong>Error Messages</strong> - Provide clear, helpful error messages</li><li><strong>Instructions</strong> - Give users clear guidance on how to complete forms</li></ul><h3>Validation</h3><p>Implement both client-side and server-side validation. Ensure error messages are associated with the relevant form controls using ARIA attributes.</p>",
-			AuthorName:  "James Wilson",
-			PublishedAt: time.Date(2023, 12, 28, 13, 10, 0, 0, time.UTC),
-			Category:    "UX Design",
-			Tags:        []string{"forms", "labels", "validation", "user experience"},
-		},
+// purgeTrash permanently deletes posts that have been in the trash for longer
+// than retention, once at startup and then every hour
+func purgeTrash(db *gorm.DB, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for {
+		purged, err := repository.PurgeTrash(db, time.Now().Add(-retention))
+		if err != nil {
+			log.Println("Failed to purge the trash:", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d posts from the trash", purged)
+		}
+		<-ticker.C
 	}
 }
 
-func filterPostsBySearch(posts []PostResponse, query string) []PostResponse {
-	query = strings.ToLower(query)
-	var filtered []PostResponse
+// seedAdminUser makes sure the account named by ADMIN_EMAIL and ADMIN_PASSWORD
+// exists and has the admin role, so a fresh database has someone who can log in
+func seedAdminUser(db *gorm.DB) error {
+	email := models.NormalizeEmail(os.Getenv("ADMIN_EMAIL"))
+	password := os.Getenv("ADMIN_PASSWORD")
+	if email == "" || password == "" {
+		return nil
+	}
 
-	for _, post := range posts {
-		if strings.Contains(strings.ToLower(post.Title), query) ||
-			strings.Contains(strings.ToLower(post.Excerpt), query) ||
-			strings.Contains(strings.ToLower(post.Category), query) {
-			filtered = append(filtered, post)
+	var user models.User
+	err := db.Where("email = ?", email).First(&user).Error
+	if err == nil {
+		if user.Role == models.RoleAdmin {
+			return nil
 		}
+		log.Printf("Granting admin role to %s", email)
+		return db.Model(&user).Update("role", models.RoleAdmin).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
 
-		// Also search in tags
-		for _, tag := range post.Tags {
-			if strings.Contains(strings.ToLower(tag), query) {
-				// Check if already added
-				found := false
-				for _, f := range filtered {
-					if f.ID == post.ID {
-						found = true
-						break
-					}
-				}
-				if !found {
-					filtered = append(filtered, post)
-				}
-				break
-			}
-		}
+	user = models.User{Email: email, Name: getEnv("ADMIN_NAME", "Admin"), Role: models.RoleAdmin}
+	if err := user.SetPassword(password); err != nil {
+		return err
+	}
+	if err := db.Create(&user).Error; err != nil {
+		return err
 	}
 
-	return filtered
+	log.Printf("Created admin user %s", email)
+	return nil
 }
 
-func initDatabase() (*gorm.DB, error) {
+func initDatabase(dataSource string) (*gorm.DB, error) {
+	if dataSource == "sqlite" {
+		// Immediate transactions take the write lock up front and wait for it,
+		// rather than failing with "database is locked" when two of them that
+		// have both read try to write, as concurrent slug allocation does
+		path := getEnv("SQLITE_PATH", "blog.db")
+		return gorm.Open(sqlite.Open(path+"?_foreign_keys=on&_busy_timeout=5000&_txlock=immediate"), &gorm.Config{})
+	}
+
 	host := getEnv("DB_HOST", "localhost")
 	port := getEnv("DB_PORT", "5432")
 	user := getEnv("DB_USER", "postgres")
@@ -350,6 +367,32 @@ func initDatabase() (*gorm.DB, error) {
 	return db, nil
 }
 
+// loadAllowlist reads a content allowlist from a JSON file shaped like
+// models.Allowlist. The file replaces the built-in allowlist entirely, and is
+// refused if it allows markup that can run script.
+func loadAllowlist(path string) (models.Allowlist, error) {
+	var allowlist models.Allowlist
+
+	f, err := os.Open(path)
+	if err != nil {
+		return allowlist, err
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&allowlist); err != nil {
+		return allowlist, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(allowlist.Elements) == 0 {
+		return allowlist, fmt.Errorf("%s: no elements allowed", path)
+	}
+	if unsafe := allowlist.Unsafe(); len(unsafe) > 0 {
+		return allowlist, fmt.Errorf("%s: unsafe markup allowed: %s", path, strings.Join(unsafe, ", "))
+	}
+	return allowlist, nil
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+type contextKey struct{}
+
+// RequireAuth rejects requests without a valid "Authorization: Bearer <token>"
+// header and makes the verified claims available through ClaimsFromContext
+func (s *TokenService) RequireAuth(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		scheme, token, ok := strings.Cut(header, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+			s.unauthorized(w, r, "Missing bearer token")
+			return
+		}
+
+		claims, err := s.ParseAccessToken(strings.TrimSpace(token))
+		if err != nil {
+			s.unauthorized(w, r, "Invalid or expired token")
+			return
+		}
+
+		next(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, claims)))
+	}
+}
+
+// ClaimsFromContext returns the claims stored by RequireAuth
+func ClaimsFromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(contextKey{}).(*Claims)
+	return claims, ok
+}
+
+func (s *TokenService) unauthorized(w http.ResponseWriter, r *http.Request, message string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="blogapp"`)
+	if s.Unauthorized != nil {
+		s.Unauthorized(w, r, message)
+		return
+	}
+	http.Error(w, message, http.StatusUnauthorized)
+}
@@ -0,0 +1,74 @@
+package auth
+
+import "blogapp/internals/models"
+
+// HasRole reports whether the token holder has one of the given roles
+func (c *Claims) HasRole(roles ...models.Role) bool {
+	for _, role := range roles {
+		if c.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanCreatePost reports whether the token holder may write new posts
+func CanCreatePost(c *Claims) bool {
+	return c.HasRole(models.RoleAdmin, models.RoleEditor, models.RoleAuthor)
+}
+
+// CanEditPost reports whether the token holder may change post. Authors may
+// only edit posts they own; posts without an author belong to editors.
+func CanEditPost(c *Claims, post *models.BlogPost) bool {
+	if c.HasRole(models.RoleAdmin, models.RoleEditor) {
+		return true
+	}
+	return c.HasRole(models.RoleAuthor) && ownsPost(c, post)
+}
+
+// CanDeletePost reports whether the token holder may delete post
+func CanDeletePost(c *Claims, post *models.BlogPost) bool {
+	return CanEditPost(c, post)
+}
+
+// CanPublishPost reports whether the token holder may change the published
+// or featured flags of any post
+func CanPublishPost(c *Claims) bool {
+	return c.HasRole(models.RoleAdmin, models.RoleEditor)
+}
+
+// CanManageUsers reports whether the token holder may list, create, update and delete users
+func CanManageUsers(c *Claims) bool {
+	return c.HasRole(models.RoleAdmin)
+}
+
+// CanManageCategories reports whether the token holder may create, update and delete categories
+func CanManageCategories(c *Claims) bool {
+	return c.HasRole(models.RoleAdmin, models.RoleEditor)
+}
+
+// CanManageTrash reports whether the token holder may list every deleted post
+func CanManageTrash(c *Claims) bool {
+	return c.HasRole(models.RoleAdmin, models.RoleEditor)
+}
+
+// CanReviewPosts reports whether the token holder may approve posts or
+// request changes to them
+func CanReviewPosts(c *Claims) bool {
+	return c.HasRole(models.RoleAdmin, models.RoleEditor)
+}
+
+// CanModerateComments reports whether the token holder may see the comment
+// moderation queue and approve, reject or mark comments as spam
+func CanModerateComments(c *Claims) bool {
+	return c.HasRole(models.RoleAdmin, models.RoleEditor)
+}
+
+// IsReviewer reports whether user may be assigned reviews
+func IsReviewer(user *models.User) bool {
+	return user.Role == models.RoleAdmin || user.Role == models.RoleEditor
+}
+
+func ownsPost(c *Claims, post *models.BlogPost) bool {
+	return post.AuthorID != nil && *post.AuthorID == c.UserID
+}
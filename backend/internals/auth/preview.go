@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PreviewTokenTTL is how long a preview link keeps working
+const PreviewTokenTTL = 24 * time.Hour
+
+// previewDomain separates preview signatures from anything else signed with
+// the same secret
+const previewDomain = "blogapp-preview"
+
+// IssuePreviewToken returns a token that lets anyone holding it read the post
+// with the given slug, whatever its status, until the returned expiry. The
+// token is the expiry in Unix seconds and an HMAC-SHA256 over slug and expiry.
+func (s *TokenService) IssuePreviewToken(slug string) (string, time.Time) {
+	expiresAt := time.Now().Add(PreviewTokenTTL).Truncate(time.Second)
+	expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+	return expiry + "." + s.previewSignature(slug, expiry), expiresAt
+}
+
+// VerifyPreviewToken reports whether token was issued for slug and has not expired
+func (s *TokenService) VerifyPreviewToken(slug, token string) bool {
+	expiry, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || !time.Now().Before(time.Unix(unix, 0)) {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(s.previewSignature(slug, expiry)))
+}
+
+func (s *TokenService) previewSignature(slug, expiry string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(previewDomain + "\x00" + slug + "\x00" + expiry))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"blogapp/internals/models"
+)
+
+const (
+	// AccessTokenTTL is how long a signed access token stays valid
+	AccessTokenTTL = 15 * time.Minute
+
+	// RefreshTokenTTL is how long a refresh token can be exchanged for a new access token
+	RefreshTokenTTL = 7 * 24 * time.Hour
+
+	issuer = "blogapp"
+)
+
+var (
+	// ErrMissingSecret is returned when JWT_SECRET is not configured
+	ErrMissingSecret = errors.New("JWT_SECRET is not set")
+
+	// ErrInvalidToken is returned for malformed, expired or wrongly signed tokens
+	ErrInvalidToken = errors.New("invalid token")
+)
+
+// Claims are the JWT claims carried by an access token
+type Claims struct {
+	UserID uint        `json:"uid"`
+	Email  string      `json:"email"`
+	Role   models.Role `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// TokenService signs and verifies HS256 access tokens
+type TokenService struct {
+	secret []byte
+
+	// Unauthorized writes the 401 for requests RequireAuth rejects. It
+	// defaults to a plain-text http.Error.
+	Unauthorized func(w http.ResponseWriter, r *http.Request, message string)
+}
+
+func NewTokenService(secret string) (*TokenService, error) {
+	if secret == "" {
+		return nil, ErrMissingSecret
+	}
+	return &TokenService{secret: []byte(secret)}, nil
+}
+
+// IssueAccessToken signs a new access token for user and returns it with its expiry
+func (s *TokenService) IssueAccessToken(user *models.User) (string, time.Time, error) {
+	now := time.Now()
+	expiresAt := now.Add(AccessTokenTTL)
+
+	claims := Claims{
+		UserID: user.ID,
+		Email:  user.Email,
+		Role:   user.Role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   strconv.FormatUint(uint64(user.ID), 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return signed, expiresAt, nil
+}
+
+// ParseAccessToken verifies the signature and expiry of an access token
+func (s *TokenService) ParseAccessToken(tokenString string) (*Claims, error) {
+	var claims Claims
+	_, err := jwt.ParseWithClaims(tokenString, &claims, func(t *jwt.Token) (interface{}, error) {
+		return s.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+// NewRefreshToken returns a random opaque refresh token and the hash to store for it
+func NewRefreshToken() (token string, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashRefreshToken(token), nil
+}
+
+// HashRefreshToken returns the hex SHA-256 digest used to look up a refresh token
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,108 @@
+// Package diff computes line-based differences between two texts
+package diff
+
+import (
+	"errors"
+	"strings"
+)
+
+// MaxChangedLines bounds the work of a diff: the lines that differ between
+// the two texts, once their common start and end are set aside, multiplied
+// together may not exceed it. The comparison table needs four bytes for each.
+const MaxChangedLines = 1000 * 1000
+
+// ErrTooLarge is returned when two texts differ in too many lines to compare
+var ErrTooLarge = errors.New("texts differ in too many lines to compare")
+
+// Op says what happened to a line going from the old text to the new one
+type Op string
+
+const (
+	// Equal lines appear in both texts
+	Equal Op = "equal"
+	// Insert lines only appear in the new text
+	Insert Op = "insert"
+	// Delete lines only appear in the old text
+	Delete Op = "delete"
+)
+
+// Line is one line of a diff
+type Line struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Lines returns the line diff turning a into b. Deletions are listed before
+// insertions wherever a block of lines was replaced. It returns ErrTooLarge
+// rather than allocate more than MaxChangedLines entries.
+func Lines(a, b string) ([]Line, error) {
+	return diffLines(splitLines(a), splitLines(b))
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+// diffLines walks a longest common subsequence table. The common prefix and
+// suffix are peeled off first since edits usually touch a small part of a post.
+func diffLines(a, b []string) ([]Line, error) {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(midA), len(midB)
+	if n > 0 && m > MaxChangedLines/n {
+		return nil, ErrTooLarge
+	}
+
+	lines := make([]Line, 0, len(a)+len(b))
+	for _, text := range a[:prefix] {
+		lines = append(lines, Line{Op: Equal, Text: text})
+	}
+
+	// lcs(i, j) is the length of the common subsequence of midA[i:] and
+	// midB[j:], kept in one flat table
+	width := m + 1
+	table := make([]int32, (n+1)*width)
+	lcs := func(i, j int) int32 { return table[i*width+j] }
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				table[i*width+j] = lcs(i+1, j+1) + 1
+			} else {
+				table[i*width+j] = max(lcs(i+1, j), lcs(i, j+1))
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && midA[i] == midB[j]:
+			lines = append(lines, Line{Op: Equal, Text: midA[i]})
+			i++
+			j++
+		case j == m || (i < n && lcs(i+1, j) >= lcs(i, j+1)):
+			lines = append(lines, Line{Op: Delete, Text: midA[i]})
+			i++
+		default:
+			lines = append(lines, Line{Op: Insert, Text: midB[j]})
+			j++
+		}
+	}
+
+	for _, text := range a[len(a)-suffix:] {
+		lines = append(lines, Line{Op: Equal, Text: text})
+	}
+	return lines, nil
+}
@@ -0,0 +1,70 @@
+package diff
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+		want []Line
+	}{
+		{"empty", "", "", []Line{}},
+		{"added to nothing", "", "one\ntwo", []Line{{Insert, "one"}, {Insert, "two"}}},
+		{"unchanged", "one\ntwo\n", "one\r\ntwo", []Line{{Equal, "one"}, {Equal, "two"}}},
+		{
+			"replaced block lists deletions first",
+			"keep\nold 1\nold 2\nend",
+			"keep\nnew\nend",
+			[]Line{{Equal, "keep"}, {Delete, "old 1"}, {Delete, "old 2"}, {Insert, "new"}, {Equal, "end"}},
+		},
+		{
+			"moved line",
+			"a\nb\nc",
+			"b\nc\na",
+			[]Line{{Delete, "a"}, {Equal, "b"}, {Equal, "c"}, {Insert, "a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lines(tt.a, tt.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got  %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func numbered(prefix string, n int) string {
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = prefix + strconv.Itoa(i)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLinesRefusesLargeRewrites(t *testing.T) {
+	if _, err := Lines(numbered("old ", 10000), numbered("new ", 10000)); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("rewriting 10000 lines: err = %v, want ErrTooLarge", err)
+	}
+
+	// A small edit to a long text only compares the lines that changed
+	long := numbered("line ", 10000)
+	edited := strings.Replace(long, "line 5000\n", "changed\n", 1)
+	lines, err := Lines(long, edited)
+	if err != nil {
+		t.Fatalf("small edit: %v", err)
+	}
+	if len(lines) != 10001 {
+		t.Errorf("small edit: %d lines, want 10001", len(lines))
+	}
+}
@@ -0,0 +1,212 @@
+// Package feed writes lists of posts as RSS 2.0 and Atom documents
+package feed
+
+import (
+	"encoding/xml"
+	"time"
+)
+
+// Feed is a list of entries to syndicate, newest first
+type Feed struct {
+	Title       string
+	Description string
+	Link        string    // Page the feed belongs to
+	Self        string    // URL the feed is served from; also the Atom feed id
+	Updated     time.Time // When any entry last changed
+	Entries     []Entry
+}
+
+// Entry is a post in a feed
+type Entry struct {
+	ID         string // Permanent id that survives changes to Link, such as a tag: URI
+	Title      string
+	Link       string
+	Author     string
+	Categories []string
+	Published  time.Time
+	Updated    time.Time
+	Summary    string // Plain text
+	Content    string // HTML; left out of the feed when empty
+}
+
+// Format is a syndication format
+type Format string
+
+const (
+	// RSS is RSS 2.0, with full content in content:encoded
+	RSS Format = "rss"
+	// Atom is the Atom Syndication Format of RFC 4287
+	Atom Format = "atom"
+)
+
+// ContentType returns the Content-Type header documents in f are served with
+func (f Format) ContentType() string {
+	return f.mediaType() + "; charset=utf-8"
+}
+
+func (f Format) mediaType() string {
+	if f == Atom {
+		return "application/atom+xml"
+	}
+	return "application/rss+xml"
+}
+
+// Render writes feed as a complete XML document in format f
+func Render(f Format, feed Feed) ([]byte, error) {
+	var document interface{}
+	if f == Atom {
+		document = newAtomFeed(feed)
+	} else {
+		document = newRSS(feed)
+	}
+
+	body, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(body, '\n')...), nil
+}
+
+type rss struct {
+	XMLName      xml.Name   `xml:"rss"`
+	Version      string     `xml:"version,attr"`
+	AtomNS       string     `xml:"xmlns:atom,attr"`
+	ContentNS    string     `xml:"xmlns:content,attr"`
+	DublinCoreNS string     `xml:"xmlns:dc,attr"`
+	Channel      rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	Self          atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Creator     string   `xml:"dc:creator,omitempty"` // RSS's own author element must be an email address
+	Categories  []string `xml:"category"`
+	Description string   `xml:"description"`
+	Content     string   `xml:"content:encoded,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+func newRSS(feed Feed) rss {
+	channel := rssChannel{
+		Title:       feed.Title,
+		Link:        feed.Link,
+		Description: feed.Description,
+		Self:        atomLink{Href: feed.Self, Rel: "self", Type: RSS.mediaType()},
+		Items:       make([]rssItem, 0, len(feed.Entries)),
+	}
+	if !feed.Updated.IsZero() {
+		channel.LastBuildDate = feed.Updated.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, entry := range feed.Entries {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       entry.Title,
+			Link:        entry.Link,
+			GUID:        rssGUID{Value: entry.ID},
+			PubDate:     entry.Published.UTC().Format(time.RFC1123Z),
+			Creator:     entry.Author,
+			Categories:  entry.Categories,
+			Description: entry.Summary,
+			Content:     entry.Content,
+		})
+	}
+
+	return rss{
+		Version:      "2.0",
+		AtomNS:       "http://www.w3.org/2005/Atom",
+		ContentNS:    "http://purl.org/rss/1.0/modules/content/",
+		DublinCoreNS: "http://purl.org/dc/elements/1.1/",
+		Channel:      channel,
+	}
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Link       atomLink       `xml:"link"`
+	Author     atomPerson     `xml:"author"`
+	Categories []atomCategory `xml:"category"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Summary    atomText       `xml:"summary"`
+	Content    *atomText      `xml:"content,omitempty"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name string `xml:"name"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+func newAtomFeed(feed Feed) atomFeed {
+	doc := atomFeed{
+		ID:       feed.Self,
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		Updated:  feed.Updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: feed.Link, Rel: "alternate", Type: "text/html"},
+			{Href: feed.Self, Rel: "self", Type: Atom.mediaType()},
+		},
+		Entries: make([]atomEntry, 0, len(feed.Entries)),
+	}
+
+	for _, entry := range feed.Entries {
+		item := atomEntry{
+			ID:        entry.ID,
+			Title:     entry.Title,
+			Link:      atomLink{Href: entry.Link, Rel: "alternate", Type: "text/html"},
+			Author:    atomPerson{Name: entry.Author},
+			Published: entry.Published.UTC().Format(time.RFC3339),
+			Updated:   entry.Updated.UTC().Format(time.RFC3339),
+			Summary:   atomText{Type: "text", Body: entry.Summary},
+		}
+		for _, category := range entry.Categories {
+			item.Categories = append(item.Categories, atomCategory{Term: category})
+		}
+		if entry.Content != "" {
+			item.Content = &atomText{Type: "html", Body: entry.Content}
+		}
+		doc.Entries = append(doc.Entries, item)
+	}
+
+	return doc
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+
+	"blogapp/internals/auth"
+	"blogapp/internals/models"
+)
+
+type AuthHandler struct {
+	db     *gorm.DB
+	tokens *auth.TokenService
+}
+
+func NewAuthHandler(db *gorm.DB, tokens *auth.TokenService) *AuthHandler {
+	return &AuthHandler{db: db, tokens: tokens}
+}
+
+// LoginRequest represents the request structure for POST /api/auth/login
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+// RefreshRequest represents the request structure for the refresh and logout endpoints
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// TokenResponse is returned by login and refresh
+type TokenResponse struct {
+	AccessToken  string              `json:"access_token"`
+	TokenType    string              `json:"token_type"`
+	ExpiresIn    int                 `json:"expires_in"`
+	RefreshToken string              `json:"refresh_token"`
+	User         models.UserResponse `json:"user"`
+}
+
+// Login handles POST /api/auth/login
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	var user models.User
+	if err := h.db.Where("email = ?", models.NormalizeEmail(req.Email)).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusUnauthorized, "Invalid email or password")
+			return
+		}
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	if !user.CheckPassword(req.Password) {
+		writeError(w, r, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
+
+	response, err := h.issueTokens(h.db, &user)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "Failed to issue token")
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// Refresh handles POST /api/auth/refresh. The presented refresh token is
+// revoked and replaced, so each refresh token can only be used once.
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	var response TokenResponse
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		var stored models.RefreshToken
+		if err := tx.Preload("User").
+			Where("token_hash = ?", auth.HashRefreshToken(req.RefreshToken)).
+			First(&stored).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if !stored.Active(now) {
+			return auth.ErrInvalidToken
+		}
+
+		// Only one concurrent refresh may consume the token
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", stored.ID).
+			Update("revoked_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return auth.ErrInvalidToken
+		}
+
+		var err error
+		response, err = h.issueTokens(tx, &stored.User)
+		return err
+	})
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, auth.ErrInvalidToken) {
+			writeError(w, r, http.StatusUnauthorized, "Invalid or expired refresh token")
+			return
+		}
+		writeError(w, r, http.StatusInternalServerError, "Failed to issue token")
+		return
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// Logout handles POST /api/auth/logout by revoking the given refresh token.
+// Access tokens already issued stay valid until they expire.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req RefreshRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	if err := h.db.Model(&models.RefreshToken{}).
+		Where("token_hash = ? AND revoked_at IS NULL", auth.HashRefreshToken(req.RefreshToken)).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	// Unknown and already revoked tokens are treated as logged out
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// issueTokens signs an access token and stores a new refresh token for user
+func (h *AuthHandler) issueTokens(tx *gorm.DB, user *models.User) (TokenResponse, error) {
+	accessToken, expiresAt, err := h.tokens.IssueAccessToken(user)
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	refreshToken, refreshHash, err := auth.NewRefreshToken()
+	if err != nil {
+		return TokenResponse{}, err
+	}
+
+	stored := models.RefreshToken{
+		UserID:    user.ID,
+		TokenHash: refreshHash,
+		ExpiresAt: time.Now().Add(auth.RefreshTokenTTL),
+	}
+	if err := tx.Omit("User").Create(&stored).Error; err != nil {
+		return TokenResponse{}, err
+	}
+
+	return TokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(time.Until(expiresAt).Seconds()),
+		RefreshToken: refreshToken,
+		User:         user.ToResponse(),
+	}, nil
+}
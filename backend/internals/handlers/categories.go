@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"blogapp/internals/auth"
+	"blogapp/internals/models"
+	"blogapp/internals/repository"
+	"blogapp/internals/slug"
+)
+
+// errInvalidParent is returned when a category's parent is missing or would create a cycle
+var errInvalidParent = errors.New("invalid parent category")
+
+// GetCategories handles GET /api/categories. Post counts include subcategories,
+// which is what the sidebar shows next to each entry.
+func (h *BlogHandler) GetCategories(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	categories, err := h.posts.ListCategories(r.Context())
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	response := models.CategoryListResponse{APIVersion: models.APIVersion, Categories: categories}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetCategory handles GET /api/categories/{slug}
+func (h *BlogHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	categories, err := h.posts.ListCategories(r.Context())
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	categorySlug := models.CategorySlug(mux.Vars(r)["slug"])
+	for _, category := range categories {
+		if category.Slug == categorySlug {
+			json.NewEncoder(w).Encode(models.CategoryDetailResponse{APIVersion: models.APIVersion, Category: category})
+			return
+		}
+	}
+
+	writeError(w, r, http.StatusNotFound, "Category not found")
+}
+
+// CategoryHandler serves the category write endpoints, which editors and admins may use
+type CategoryHandler struct {
+	db *gorm.DB
+}
+
+func NewCategoryHandler(db *gorm.DB) *CategoryHandler {
+	return &CategoryHandler{db: db}
+}
+
+// CreateCategory handles POST /api/categories
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !h.authorize(w, r) {
+		return
+	}
+
+	var req models.CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	var category models.Category
+	applyCategoryRequest(&category, &req)
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkParent(tx, &category); err != nil {
+			return err
+		}
+		return tx.Create(&category).Error
+	})
+	if !h.handleWriteError(w, r, err) {
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.CategoryDetailResponse{APIVersion: models.APIVersion, Category: category.ToResponse(0)})
+}
+
+// UpdateCategory handles PUT /api/categories/{id}
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !h.authorize(w, r) {
+		return
+	}
+
+	category, ok := h.findCategory(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CategoryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	applyCategoryRequest(category, &req)
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := checkParent(tx, category); err != nil {
+			return err
+		}
+		return tx.Omit("Parent").Save(category).Error
+	})
+	if !h.handleWriteError(w, r, err) {
+		return
+	}
+
+	var postCount int64
+	ids, err := repository.CategoryTreeIDs(h.db, category.ID)
+	if err == nil {
+		err = repository.Live(h.db.Model(&models.BlogPost{})).
+			Where("blog_posts.category_id IN ?", ids).
+			Count(&postCount).Error
+	}
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.CategoryDetailResponse{APIVersion: models.APIVersion, Category: category.ToResponse(postCount)})
+}
+
+// DeleteCategory handles DELETE /api/categories/{id}. Subcategories move up to
+// the deleted category's parent and its posts are left without a category.
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	category, ok := h.findCategory(w, r)
+	if !ok {
+		return
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Category{}).
+			Where("parent_id = ?", category.ID).
+			Update("parent_id", category.ParentID).Error; err != nil {
+			return err
+		}
+		return tx.Delete(category).Error
+	})
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorize checks that the caller may manage categories
+func (h *CategoryHandler) authorize(w http.ResponseWriter, r *http.Request) bool {
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return false
+	}
+
+	if !auth.CanManageCategories(claims) {
+		writeError(w, r, http.StatusForbidden, "Only editors can manage categories")
+		return false
+	}
+	return true
+}
+
+// findCategory loads the category named by the {id} route variable
+func (h *CategoryHandler) findCategory(w http.ResponseWriter, r *http.Request) (*models.Category, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "Invalid category id")
+		return nil, false
+	}
+
+	var category models.Category
+	if err := h.db.First(&category, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusNotFound, "Category not found")
+			return nil, false
+		}
+		writeDatabaseError(w, r, err)
+		return nil, false
+	}
+	return &category, true
+}
+
+// handleWriteError reports a failed create or update and returns false, or
+// returns true when err is nil
+func (h *CategoryHandler) handleWriteError(w http.ResponseWriter, r *http.Request, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errInvalidParent):
+		writeError(w, r, http.StatusBadRequest, "Parent category does not exist or is a subcategory of this one")
+	case slug.IsUniqueViolation(err):
+		writeError(w, r, http.StatusConflict, "A category with this slug already exists")
+	default:
+		writeDatabaseError(w, r, err)
+	}
+	return false
+}
+
+// applyCategoryRequest copies req onto category, deriving the slug from the
+// name when none is given
+func applyCategoryRequest(category *models.Category, req *models.CategoryRequest) {
+	slugBase := req.Slug
+	if slugBase == "" {
+		slugBase = req.Name
+	}
+
+	category.Name = req.Name
+	category.Slug = models.CategorySlug(slugBase)
+	category.Description = req.Description
+	category.ParentID = req.ParentID
+}
+
+// checkParent makes sure the category's parent exists and is not the category
+// itself or one of its descendants
+func checkParent(tx *gorm.DB, category *models.Category) error {
+	if category.ParentID == nil {
+		return nil
+	}
+
+	var parent models.Category
+	if err := tx.First(&parent, *category.ParentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errInvalidParent
+		}
+		return err
+	}
+
+	if category.ID == 0 {
+		return nil
+	}
+
+	descendants, err := repository.CategoryTreeIDs(tx, category.ID)
+	if err != nil {
+		return err
+	}
+	for _, id := range descendants {
+		if id == parent.ID {
+			return errInvalidParent
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"blogapp/internals/auth"
+	"blogapp/internals/models"
+	"blogapp/internals/repository"
+)
+
+// errParentNotApproved is returned when a reply is approved before the comment it answers
+var errParentNotApproved = errors.New("parent comment not approved")
+
+// GetComments handles GET /api/posts/{slug}/comments: the approved comments
+// on a published post, threaded, oldest first
+func (h *BlogHandler) GetComments(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	post, ok := h.findLivePost(w, r)
+	if !ok {
+		return
+	}
+
+	var comments []models.Comment
+	if err := h.db.Where("post_id = ? AND status = ?", post.ID, models.CommentApproved).
+		Order("created_at, id").
+		Find(&comments).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.CommentThreadResponse{
+		APIVersion: models.APIVersion,
+		Count:      len(comments),
+		Comments:   models.NewCommentThread(comments),
+	})
+}
+
+// CreateComment handles POST /api/posts/{slug}/comments. Anyone may comment
+// on a published post; the comment is held for moderation and the body is
+// sanitised before it is stored. Setting parent_id replies to an approved comment.
+func (h *BlogHandler) CreateComment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	post, ok := h.findLivePost(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.CreateCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+
+	req.AuthorName = strings.TrimSpace(req.AuthorName)
+	req.AuthorEmail = models.NormalizeEmail(req.AuthorEmail)
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	body := models.SanitizeComment(req.Body)
+	if body == "" {
+		writeError(w, r, http.StatusUnprocessableEntity, "Comment body is empty once markup is removed")
+		return
+	}
+
+	if req.ParentID != nil {
+		var parents int64
+		if err := h.db.Model(&models.Comment{}).
+			Where("id = ? AND post_id = ? AND status = ?", *req.ParentID, post.ID, models.CommentApproved).
+			Count(&parents).Error; err != nil {
+			writeDatabaseError(w, r, err)
+			return
+		}
+		if parents == 0 {
+			writeError(w, r, http.StatusBadRequest, "Unknown parent comment")
+			return
+		}
+	}
+
+	comment := models.Comment{
+		PostID:      post.ID,
+		ParentID:    req.ParentID,
+		AuthorName:  req.AuthorName,
+		AuthorEmail: req.AuthorEmail,
+		Body:        body,
+		Status:      models.CommentPending,
+	}
+	if err := h.db.Create(&comment).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.CreateCommentResponse{
+		APIVersion: models.APIVersion,
+		ID:         comment.ID,
+		Status:     comment.Status,
+		Message:    "Your comment will appear once a moderator has approved it",
+	})
+}
+
+// GetModerationQueue handles GET /api/admin/comments. It lists the comments
+// with the given ?status=, pending by default: the pending queue oldest
+// first, any other status newest first. page and limit work as on GET /api/posts.
+func (h *BlogHandler) GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !h.authorizeModeration(w, r) {
+		return
+	}
+
+	status := models.CommentPending
+	if value := r.URL.Query().Get("status"); value != "" {
+		status = models.CommentStatus(value)
+	}
+	if !status.Valid() {
+		writeError(w, r, http.StatusBadRequest, "Unknown comment status")
+		return
+	}
+
+	q, err := parsePostQuery(r, "search")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	queue := h.db.Model(&models.Comment{}).Where("status = ?", status)
+
+	var totalCount int64
+	if err := queue.Session(&gorm.Session{}).Count(&totalCount).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	order := "created_at DESC, id DESC"
+	if status == models.CommentPending {
+		order = "created_at, id"
+	}
+
+	var comments []models.Comment
+	if err := queue.Preload("Post", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).
+		Order(order).
+		Offset(q.Offset()).Limit(q.Limit).
+		Find(&comments).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.NewModerationListResponse(status, comments, q.Page, q.Limit, totalCount))
+}
+
+// ApproveComment handles POST /api/admin/comments/{id}/approve. A reply can
+// only be approved once the comment it answers is.
+func (h *BlogHandler) ApproveComment(w http.ResponseWriter, r *http.Request) {
+	h.moderateComment(w, r, models.CommentApproved)
+}
+
+// RejectComment handles POST /api/admin/comments/{id}/reject
+func (h *BlogHandler) RejectComment(w http.ResponseWriter, r *http.Request) {
+	h.moderateComment(w, r, models.CommentRejected)
+}
+
+// MarkCommentSpam handles POST /api/admin/comments/{id}/spam
+func (h *BlogHandler) MarkCommentSpam(w http.ResponseWriter, r *http.Request) {
+	h.moderateComment(w, r, models.CommentSpam)
+}
+
+// moderateComment moves the comment named by the {id} route variable to
+// status. Moderators may change their minds, so any status can follow any other.
+func (h *BlogHandler) moderateComment(w http.ResponseWriter, r *http.Request, status models.CommentStatus) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !h.authorizeModeration(w, r) {
+		return
+	}
+
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "Invalid comment id")
+		return
+	}
+
+	var comment models.Comment
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&comment, id).Error; err != nil {
+			return err
+		}
+
+		if status == models.CommentApproved && comment.ParentID != nil {
+			var parents int64
+			if err := tx.Model(&models.Comment{}).
+				Where("id = ? AND status = ?", *comment.ParentID, models.CommentApproved).
+				Count(&parents).Error; err != nil {
+				return err
+			}
+			if parents == 0 {
+				return errParentNotApproved
+			}
+		}
+
+		if comment.Status == status {
+			return nil
+		}
+
+		// The post's comment count is part of its representation, so
+		// cached copies are invalidated whenever it changes
+		if comment.Status == models.CommentApproved || status == models.CommentApproved {
+			if err := tx.Unscoped().Model(&models.BlogPost{}).
+				Where("id = ?", comment.PostID).
+				UpdateColumn("version", gorm.Expr("version + 1")).Error; err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		comment.Status = status
+		comment.ModeratedAt = &now
+		return tx.Model(&comment).Updates(map[string]interface{}{
+			"status":       status,
+			"moderated_at": now,
+		}).Error
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			writeError(w, r, http.StatusNotFound, "Comment not found")
+		case errors.Is(err, errParentNotApproved):
+			writeError(w, r, http.StatusConflict, "Approve the comment this one replies to first")
+		default:
+			writeDatabaseError(w, r, err)
+		}
+		return
+	}
+
+	if err := h.db.Unscoped().Where("id = ?", comment.PostID).First(&comment.Post).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.ModerationDetailResponse{
+		APIVersion: models.APIVersion,
+		Comment:    comment.ToModerationResponse(),
+	})
+}
+
+// authorizeModeration checks that the caller may moderate comments
+func (h *BlogHandler) authorizeModeration(w http.ResponseWriter, r *http.Request) bool {
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return false
+	}
+
+	if !auth.CanModerateComments(claims) {
+		writeError(w, r, http.StatusForbidden, "Only editors can moderate comments")
+		return false
+	}
+	return true
+}
+
+// findLivePost loads the published post named by the {slug} route variable
+func (h *BlogHandler) findLivePost(w http.ResponseWriter, r *http.Request) (*models.BlogPost, bool) {
+	post, err := h.posts.GetPublishedBySlug(r.Context(), mux.Vars(r)["slug"])
+	if err != nil {
+		if errors.Is(err, repository.ErrPostNotFound) {
+			writeError(w, r, http.StatusNotFound, "Blog post not found")
+			return nil, false
+		}
+		writeDatabaseError(w, r, err)
+		return nil, false
+	}
+	return post, true
+}
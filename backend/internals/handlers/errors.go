@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"blogapp/internals/validation"
+)
+
+// Error codes sent in APIError.Code. Clients should branch on these rather
+// than on the message, which is meant for people.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeInvalidJSON      = "invalid_json"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeConflict         = "conflict"
+	CodePreconditionFail = "precondition_failed"
+	CodeUnsupportedMedia = "unsupported_media_type"
+	CodeValidationFailed = "validation_failed"
+	CodeInternal         = "internal_error"
+)
+
+// RequestIDHeader carries the request id in both directions
+const RequestIDHeader = "X-Request-ID"
+
+// APIError is the body of every error response, wrapped as {"error": {...}}.
+// Details lists the invalid fields of a request that failed validation.
+type APIError struct {
+	Status    int                     `json:"-"`
+	Code      string                  `json:"code"`
+	Message   string                  `json:"message"`
+	Details   []validation.FieldError `json:"details,omitempty"`
+	RequestID string                  `json:"request_id,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	return e.Message
+}
+
+// ErrorResponse is the envelope APIError is sent in
+type ErrorResponse struct {
+	Error *APIError `json:"error"`
+}
+
+// NewAPIError returns an error with the code that matches status
+func NewAPIError(status int, message string) *APIError {
+	return &APIError{Status: status, Code: codeForStatus(status), Message: message}
+}
+
+func codeForStatus(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusPreconditionFailed:
+		return CodePreconditionFail
+	case http.StatusUnsupportedMediaType:
+		return CodeUnsupportedMedia
+	case http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	}
+	return CodeInternal
+}
+
+// writeAPIError sends e as the response, stamped with the request's id
+func writeAPIError(w http.ResponseWriter, r *http.Request, e *APIError) {
+	e.RequestID = RequestIDFromContext(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: e})
+}
+
+// writeError replaces http.Error for API routes
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeAPIError(w, r, NewAPIError(status, message))
+}
+
+// writeInvalidJSON reports a request body that could not be decoded
+func writeInvalidJSON(w http.ResponseWriter, r *http.Request) {
+	writeAPIError(w, r, &APIError{Status: http.StatusBadRequest, Code: CodeInvalidJSON, Message: "Invalid JSON"})
+}
+
+// writeDatabaseError logs err and reports it to the client without the details
+func writeDatabaseError(w http.ResponseWriter, r *http.Request, err error) {
+	log.Printf("request %s: %s %s: %v", RequestIDFromContext(r.Context()), r.Method, r.URL.Path, err)
+	writeError(w, r, http.StatusInternalServerError, "Database error")
+}
+
+// NotFound answers requests for API paths that match no route
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusNotFound, "No route matches "+r.URL.Path)
+}
+
+// methodNotAllowed answers requests for a route that exists under other methods
+func methodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeError(w, r, http.StatusMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path)
+}
+
+// UnmatchedHandler serves as both the NotFoundHandler and the
+// MethodNotAllowedHandler of router. mux does not reliably tell the two apart
+// when routes share a path, so this probes the other methods itself and answers
+// 405 with an Allow header when the path exists, or 404 when it does not.
+func UnmatchedHandler(router *mux.Router) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var allowed []string
+		for _, method := range []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete} {
+			if method == r.Method {
+				continue
+			}
+			probe := r.Clone(r.Context())
+			probe.Method = method
+
+			var match mux.RouteMatch
+			if router.Match(probe, &match) && match.MatchErr == nil {
+				allowed = append(allowed, method)
+			}
+		}
+
+		if len(allowed) == 0 {
+			NotFound(w, r)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(allowed, ", "))
+		methodNotAllowed(w, r)
+	})
+}
+
+// Unauthorized is used by auth.TokenService to reject requests without a valid token
+func Unauthorized(w http.ResponseWriter, r *http.Request, message string) {
+	writeError(w, r, http.StatusUnauthorized, message)
+}
+
+type requestIDKey struct{}
+
+// RequestID gives every request an id, taken from a well-formed X-Request-ID
+// header or generated, and echoes it in the response so clients can quote it
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+// RequestIDFromContext returns the id stored by RequestID
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID accepts up to 64 visible ASCII characters, so a client id
+// cannot inject anything into headers or logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"gorm.io/gorm"
+
+	"blogapp/internals/models"
+)
+
+// errVersionConflict is returned when a post changed between being loaded and saved
+var errVersionConflict = errors.New("post was modified concurrently")
+
+// checkIfMatch enforces an If-Match header against the post's ETag. It writes
+// a 412 and returns false when the client's copy is out of date.
+func checkIfMatch(w http.ResponseWriter, r *http.Request, post *models.BlogPost) bool {
+	header := r.Header.Get("If-Match")
+	if header == "" || etagMatches(header, post.ETag()) {
+		return true
+	}
+
+	w.Header().Set("ETag", post.ETag())
+	writeError(w, r, http.StatusPreconditionFailed, "The post has changed since it was fetched; reload it and try again")
+	return false
+}
+
+// writeVersionConflict reports a save that lost a race with another writer.
+// Clients that sent If-Match get the 412 they asked for; others get a 409.
+func writeVersionConflict(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("If-Match") != "" {
+		writeError(w, r, http.StatusPreconditionFailed, "The post has changed since it was fetched; reload it and try again")
+		return
+	}
+	writeError(w, r, http.StatusConflict, "The post was changed by another request; reload it and try again")
+}
+
+// claimNextVersion bumps the post's version if it still has the one that was
+// loaded, so two concurrent saves cannot both succeed
+func claimNextVersion(tx *gorm.DB, post *models.BlogPost) error {
+	result := tx.Model(&models.BlogPost{}).
+		Where("id = ? AND version = ?", post.ID, post.Version).
+		UpdateColumn("version", gorm.Expr("version + 1"))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errVersionConflict
+	}
+	post.Version++
+	return nil
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header lists etag.
+// Weak validators compare equal to their strong form, which is what
+// If-None-Match calls for and harmless for If-Match since ours are all strong.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
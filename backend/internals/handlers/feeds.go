@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"blogapp/internals/feed"
+	"blogapp/internals/models"
+	"blogapp/internals/repository"
+)
+
+// defaultFeedSize is how many posts a feed lists when FeedSettings.Size is unset
+const defaultFeedSize = 20
+
+// FeedSettings describes the site in its RSS and Atom feeds
+type FeedSettings struct {
+	Title       string
+	Description string
+	SiteURL     string // Absolute URL of the site, without a trailing slash
+	FullContent bool   // Publish whole posts rather than their excerpts
+	Size        int    // Newest posts listed in each feed
+}
+
+// GetFeed handles GET /feed.xml (RSS 2.0) and GET /atom.xml: the newest
+// published posts
+func (h *BlogHandler) GetFeed(w http.ResponseWriter, r *http.Request) {
+	h.serveFeed(w, r, repository.PostQuery{}, h.Feeds.Title, h.Feeds.Description)
+}
+
+// GetCategoryFeed handles GET /category/{slug}/feed.xml and
+// /category/{slug}/atom.xml. Posts in subcategories are included.
+func (h *BlogHandler) GetCategoryFeed(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.posts.ListCategories(r.Context())
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	categorySlug := models.CategorySlug(mux.Vars(r)["slug"])
+	for _, category := range categories {
+		if category.Slug == categorySlug {
+			h.serveFeed(w, r, repository.PostQuery{Category: category.Slug},
+				fmt.Sprintf("%s: %s", h.Feeds.Title, category.Name),
+				fmt.Sprintf("Posts filed under %s", category.Name))
+			return
+		}
+	}
+
+	writeError(w, r, http.StatusNotFound, "Category not found")
+}
+
+// GetTagFeed handles GET /tag/{tag}/feed.xml and /tag/{tag}/atom.xml. {tag}
+// may be the tag's slug or its name.
+func (h *BlogHandler) GetTagFeed(w http.ResponseWriter, r *http.Request) {
+	tag, err := h.posts.GetTag(r.Context(), models.TagSlug(mux.Vars(r)["tag"]))
+	if err != nil {
+		if errors.Is(err, repository.ErrTagNotFound) {
+			writeError(w, r, http.StatusNotFound, "Tag not found")
+			return
+		}
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	h.serveFeed(w, r, repository.PostQuery{Tag: tag.Slug},
+		fmt.Sprintf("%s: %s", h.Feeds.Title, tag.Name),
+		fmt.Sprintf("Posts tagged %s", tag.Name))
+}
+
+// serveFeed writes the newest published posts matching q as RSS or Atom, as
+// picked by the {format} route variable. The ETag is a hash of the document
+// and Last-Modified the latest change to a listed post, so conditional
+// requests get a 304 until the feed would read differently.
+func (h *BlogHandler) serveFeed(w http.ResponseWriter, r *http.Request, q repository.PostQuery, title, description string) {
+	format := feed.RSS
+	if mux.Vars(r)["format"] == "atom" {
+		format = feed.Atom
+	}
+
+	q.Page = 1
+	q.Limit = h.Feeds.Size
+	if q.Limit <= 0 {
+		q.Limit = defaultFeedSize
+	}
+
+	posts, _, err := h.posts.ListPublished(r.Context(), q)
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	doc := feed.Feed{
+		Title:       title,
+		Description: description,
+		Link:        h.Feeds.SiteURL + "/",
+		Self:        h.Feeds.SiteURL + r.URL.Path,
+		Entries:     make([]feed.Entry, 0, len(posts)),
+	}
+	for i := range posts {
+		entry := h.feedEntry(&posts[i])
+		if entry.Updated.After(doc.Updated) {
+			doc.Updated = entry.Updated
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	lastModified := doc.Updated
+	if doc.Updated.IsZero() {
+		// Atom requires a date even when there is nothing in the feed
+		doc.Updated = time.Unix(0, 0)
+	}
+
+	body, err := feed.Render(format, doc)
+	if err != nil {
+		writeError(w, r, http.StatusInternalServerError, "Failed to build the feed")
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	http.ServeContent(w, r, "", lastModified, bytes.NewReader(body))
+}
+
+// feedEntry converts a published post to a feed entry. Its id is a tag: URI
+// built from the post's id, so it stays the same when the slug changes.
+func (h *BlogHandler) feedEntry(post *models.BlogPost) feed.Entry {
+	entry := feed.Entry{
+		ID:      fmt.Sprintf("tag:%s,%s:posts/%d", feedHost(h.Feeds.SiteURL), post.CreatedAt.UTC().Format("2006-01-02"), post.ID),
+		Title:   post.Title,
+		Link:    h.Feeds.SiteURL + "/#!/post/" + url.PathEscape(post.Slug),
+		Author:  post.AuthorName,
+		Updated: post.UpdatedAt,
+		Summary: post.Excerpt,
+	}
+
+	entry.Published = post.CreatedAt
+	if post.PublishedAt != nil {
+		entry.Published = *post.PublishedAt
+	}
+	// Scheduled posts go live without being saved again
+	if entry.Published.After(entry.Updated) {
+		entry.Updated = entry.Published
+	}
+
+	if post.Category != nil {
+		entry.Categories = append(entry.Categories, post.Category.Name)
+	}
+	entry.Categories = append(entry.Categories, post.TagList()...)
+
+	if h.Feeds.FullContent {
+		entry.Content = post.HTML()
+	}
+	return entry
+}
+
+// feedHost returns the host name of siteURL for use in tag: URIs
+func feedHost(siteURL string) string {
+	if u, err := url.Parse(siteURL); err == nil && u.Hostname() != "" {
+		return u.Hostname()
+	}
+	return "localhost"
+}
@@ -2,227 +2,552 @@ package handlers
 
 import (
 	"encoding/json"
-	"math"
+	"errors"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"gorm.io/gorm"
 
+	"blogapp/internals/auth"
 	"blogapp/internals/models"
+	"blogapp/internals/repository"
+	"blogapp/internals/slug"
 )
 
 type BlogHandler struct {
-	db *gorm.DB
+	db    *gorm.DB
+	posts repository.PostRepository
+	slugs *slug.Service
+
+	// Previews signs and checks ?preview= tokens for unpublished posts. Previews
+	// are disabled while it is nil.
+	Previews *auth.TokenService
+
+	// RequireApproval stops posts from being published or scheduled until one
+	// of their reviews has been approved
+	RequireApproval bool
+
+	// Feeds describes the site in the RSS and Atom feeds
+	Feeds FeedSettings
 }
 
-func NewBlogHandler(db *gorm.DB) *BlogHandler {
-	return &BlogHandler{db: db}
+// NewBlogHandler wires the handler to its post repository. db may be nil when
+// the server runs against fixture data, in which case only the GET routes are usable.
+func NewBlogHandler(db *gorm.DB, posts repository.PostRepository) *BlogHandler {
+	return &BlogHandler{db: db, posts: posts, slugs: slug.NewService(db)}
 }
 
-// GetPosts handles GET /api/posts with pagination and search
+// GetPosts handles GET /api/posts with pagination and search. Searches use the
+// database's full-text index and are ordered by relevance.
 func (h *BlogHandler) GetPosts(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	// Parse query parameters
-	pageStr := r.URL.Query().Get("page")
-	limitStr := r.URL.Query().Get("limit")
-	search := r.URL.Query().Get("search")
-	category := r.URL.Query().Get("category")
-	featured := r.URL.Query().Get("featured")
-
-	// Set defaults
-	page := 1
-	limit := 6
-
-	if pageStr != "" {
-		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
-			page = p
-		}
+	q, err := parsePostQuery(r, "search")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
 	}
+	q.Featured = r.URL.Query().Get("featured") == "true"
 
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 50 {
-			limit = l
-		}
+	posts, totalCount, err := h.posts.ListPublished(r.Context(), q)
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
 	}
 
-	// Calculate offset
-	offset := (page - 1) * limit
+	response := models.NewPostListResponse(posts, q.Page, q.Limit, totalCount)
+	json.NewEncoder(w).Encode(response)
+}
 
-	// Build query
-	query := h.db.Model(&models.BlogPost{}).Where("published = ?", true)
+// SearchPosts handles GET /api/search. It returns a page of ranked hits for q
+// together with facet counts over every match, so the filters shown next to
+// the results can be combined, e.g. category=Legal&tag=ADA&year=2024.
+func (h *BlogHandler) SearchPosts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
 
-	// Apply search filter
-	if search != "" {
-		searchPattern := "%" + strings.ToLower(search) + "%"
-		query = query.Where(
-			"LOWER(title) LIKE ? OR LOWER(content) LIKE ? OR LOWER(excerpt) LIKE ? OR LOWER(tags) LIKE ?",
-			searchPattern, searchPattern, searchPattern, searchPattern,
-		)
+	q, err := parsePostQuery(r, "q")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
 	}
 
-	// Apply category filter
-	if category != "" {
-		query = query.Where("LOWER(category) = ?", strings.ToLower(category))
+	posts, totalCount, err := h.posts.ListPublished(r.Context(), q)
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
 	}
 
-	// Apply featured filter
-	if featured == "true" {
-		query = query.Where("featured = ?", true)
+	facets, err := h.posts.Facets(r.Context(), q)
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
 	}
 
-	// Get total count
-	var totalCount int64
-	if err := query.Count(&totalCount).Error; err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
+	response := models.SearchResponse{
+		PostListResponse: models.NewPostListResponse(posts, q.Page, q.Limit, totalCount),
+		Query:            q.Search,
+		Facets:           facets,
 	}
+	json.NewEncoder(w).Encode(response)
+}
 
-	// Get posts with pagination
-	var posts []models.BlogPost
-	if err := query.
-		Order("published_at DESC, created_at DESC").
-		Offset(offset).
-		Limit(limit).
-		Find(&posts).Error; err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
-		return
+// parsePostQuery reads the pagination, search and filter parameters shared by
+// the list and search endpoints. searchParam names the search text parameter.
+func parsePostQuery(r *http.Request, searchParam string) (repository.PostQuery, error) {
+	params := r.URL.Query()
+
+	// Set defaults
+	q := repository.PostQuery{
+		Page:       1,
+		Limit:      6,
+		Search:     strings.TrimSpace(params.Get(searchParam)),
+		SearchMode: repository.SearchFullText,
+		Category:   params.Get("category"),
+		Tag:        params.Get("tag"),
+		Author:     params.Get("author"),
 	}
 
-	// Convert to response format
-	var postResponses []models.BlogPostResponse
-	for _, post := range posts {
-		postResponses = append(postResponses, post.ToResponse(false))
+	// search_mode=substring keeps the old partial-word matching
+	if params.Get("search_mode") == string(repository.SearchSubstring) {
+		q.SearchMode = repository.SearchSubstring
 	}
 
-	// Calculate pagination info
-	totalPages := int(math.Ceil(float64(totalCount) / float64(limit)))
+	if pageStr := params.Get("page"); pageStr != "" {
+		if p, err := strconv.Atoi(pageStr); err == nil && p > 0 {
+			q.Page = p
+		}
+	}
 
-	response := models.PaginatedResponse{
-		Posts:       postResponses,
-		CurrentPage: page,
-		TotalPages:  totalPages,
-		TotalPosts:  totalCount,
-		HasNext:     page < totalPages,
-		HasPrev:     page > 1,
+	if limitStr := params.Get("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 50 {
+			q.Limit = l
+		}
 	}
 
-	json.NewEncoder(w).Encode(response)
+	if yearStr := params.Get("year"); yearStr != "" {
+		year, err := strconv.Atoi(yearStr)
+		if err != nil || year < 1 || year > 9999 {
+			return q, errors.New("Invalid year")
+		}
+		q.Year = year
+	}
+
+	return q, nil
 }
 
-// GetPostBySlug handles GET /api/posts/{slug}
+// GetPostBySlug handles GET /api/posts/{slug}. A slug the post used to have
+// answers with a 301 to the current one, so shared links keep working.
 func (h *BlogHandler) GetPostBySlug(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
 	vars := mux.Vars(r)
 	slug := vars["slug"]
 
-	var post models.BlogPost
-	if err := h.db.Where("slug = ? AND published = ?", slug, true).First(&post).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Blog post not found", http.StatusNotFound)
+	if token := r.URL.Query().Get("preview"); token != "" {
+		h.previewPost(w, r, slug, token)
+		return
+	}
+
+	post, err := h.posts.GetPublishedBySlug(r.Context(), slug)
+	if errors.Is(err, repository.ErrPostNotFound) {
+		h.redirectOldSlug(w, r, slug)
+		return
+	}
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", post.ETag())
+	if etagMatches(r.Header.Get("If-None-Match"), post.ETag()) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	response := models.NewPostDetailResponse(post)
+	json.NewEncoder(w).Encode(response)
+}
+
+// redirectOldSlug answers a request for a slug that is not current, either
+// with a 301 to the post that used to have it or with a 404
+func (h *BlogHandler) redirectOldSlug(w http.ResponseWriter, r *http.Request, oldSlug string) {
+	current, err := h.posts.ResolveSlug(r.Context(), oldSlug)
+	if err != nil {
+		if errors.Is(err, repository.ErrPostNotFound) {
+			writeError(w, r, http.StatusNotFound, "Blog post not found")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		writeDatabaseError(w, r, err)
 		return
 	}
 
-	response := post.ToResponse(true)
-	json.NewEncoder(w).Encode(response)
+	location := "/api/posts/" + url.PathEscape(current)
+	if r.URL.RawQuery != "" {
+		location += "?" + r.URL.RawQuery
+	}
+
+	w.Header().Set("Location", location)
+	w.WriteHeader(http.StatusMovedPermanently)
+	json.NewEncoder(w).Encode(models.NewPostRedirectResponse(current, location))
 }
 
-// CreatePost handles POST /api/posts
+// CreatePost handles POST /api/posts. The post is owned by the signed-in user;
+// only editors and admins may create it already published or featured.
 func (h *BlogHandler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	if !auth.CanCreatePost(claims) {
+		writeError(w, r, http.StatusForbidden, "You are not allowed to create posts")
+		return
+	}
+
 	var req models.CreateBlogPostRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeInvalidJSON(w, r)
 		return
 	}
 
-	// Validate required fields
-	if req.Title == "" || req.Content == "" || req.AuthorName == "" {
-		http.Error(w, "Missing required fields", http.StatusBadRequest)
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	status := req.InitialStatus()
+	if (!status.Editorial() || req.Featured) && !auth.CanPublishPost(claims) {
+		writeError(w, r, http.StatusForbidden, "Only editors can publish, schedule, archive or feature posts")
+		return
+	}
+	if !checkStatusChange(w, r, "", status, req.PublishedAt, true) || !h.checkApproval(w, r, nil, "", status, false) {
+		return
+	}
+
+	var author models.User
+	if err := h.db.First(&author, claims.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusUnauthorized, "User no longer exists")
+			return
+		}
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	category, ok := h.findPostCategory(w, r, req.Category)
+	if !ok {
 		return
 	}
 
 	// Create blog post
 	post := req.ToBlogPost()
+	post.AuthorID = &author.ID
+	post.SetCategory(category)
+	if post.AuthorName == "" {
+		post.AuthorName = author.Name
+	}
+
+	// An explicit slug is used as the base instead of the title
+	slugBase := req.Slug
+	if slugBase == "" {
+		slugBase = post.Title
+	}
 
-	if err := h.db.Create(&post).Error; err != nil {
-		if strings.Contains(err.Error(), "duplicate key") {
-			http.Error(w, "Blog post with this slug already exists", http.StatusConflict)
+	err := h.slugs.Create(r.Context(), slugBase, func(tx *gorm.DB, slug string) error {
+		tags, err := repository.FindOrCreateTags(tx, req.Tags)
+		if err != nil {
+			return err
+		}
+		post.SetTags(tags)
+		post.Slug = slug
+		if err := tx.Create(&post).Error; err != nil {
+			return err
+		}
+		revision := models.NewPostRevision(&post, &author)
+		return tx.Create(&revision).Error
+	})
+	if err != nil {
+		if slug.IsUniqueViolation(err) {
+			writeError(w, r, http.StatusConflict, "Blog post with this slug already exists")
 			return
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		writeDatabaseError(w, r, err)
 		return
 	}
 
-	response := post.ToResponse(true)
+	w.Header().Set("ETag", post.ETag())
+	response := models.NewPostDetailResponse(&post)
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(response)
 }
 
-// UpdatePost handles PUT /api/posts/{slug}
+// UpdatePost handles PUT /api/posts/{slug}, which replaces every editable
+// field. Authors may update their own posts; changing the published or
+// featured flags requires an editor or admin.
 func (h *BlogHandler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
-	vars := mux.Vars(r)
-	slug := vars["slug"]
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
 
 	var req models.CreateBlogPostRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		writeInvalidJSON(w, r)
 		return
 	}
 
-	// Find existing post
-	var existingPost models.BlogPost
-	if err := h.db.Where("slug = ?", slug).First(&existingPost).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Blog post not found", http.StatusNotFound)
-			return
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	existingPost, ok := h.findEditablePost(w, r, claims)
+	if !ok || !checkIfMatch(w, r, existingPost) {
+		return
+	}
+
+	h.savePost(w, r, claims, existingPost, &req)
+}
+
+// PatchPost handles PATCH /api/posts/{slug}. The body is a JSON Merge Patch
+// (RFC 7396) against the post's fields as PUT takes them: fields left out keep
+// their value, null clears one, and everything else replaces it.
+func (h *BlogHandler) PatchPost(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	if !isMergePatch(r) {
+		w.Header().Set("Accept-Patch", mergePatchMediaType)
+		writeError(w, r, http.StatusUnsupportedMediaType, "PATCH bodies must be "+mergePatchMediaType)
+		return
+	}
+
+	var patch interface{}
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+
+	existingPost, ok := h.findEditablePost(w, r, claims)
+	if !ok || !checkIfMatch(w, r, existingPost) {
+		return
+	}
+
+	req, err := applyMergePatch(existingPost.ToRequest(), patch)
+	if err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	h.savePost(w, r, claims, existingPost, &req)
+}
+
+// findEditablePost loads the post named by the {slug} route variable, with its
+// tags and category, and checks that the caller may edit it
+func (h *BlogHandler) findEditablePost(w http.ResponseWriter, r *http.Request, claims *auth.Claims) (*models.BlogPost, bool) {
+	var post models.BlogPost
+	if err := h.db.Preload("Tags").Preload("Category").Where("slug = ?", mux.Vars(r)["slug"]).First(&post).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusNotFound, "Blog post not found")
+			return nil, false
 		}
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		writeDatabaseError(w, r, err)
+		return nil, false
+	}
+
+	if !auth.CanEditPost(claims, &post) {
+		writeError(w, r, http.StatusForbidden, "You are not allowed to edit this post")
+		return nil, false
+	}
+	return &post, true
+}
+
+// savePost writes req over existingPost and responds with the updated post
+func (h *BlogHandler) savePost(w http.ResponseWriter, r *http.Request, claims *auth.Claims, existingPost *models.BlogPost, req *models.CreateBlogPostRequest) {
+	// A null or missing published_at keeps the current date
+	status := req.RequestedStatus(existingPost.Status, existingPost.Published)
+	publishedAt := existingPost.PublishedAt
+	if req.PublishedAt != nil {
+		publishedAt = req.PublishedAt
+	}
+	statusChanged := status != existingPost.Status
+	dateChanged := !sameTime(publishedAt, existingPost.PublishedAt)
+
+	// Authors may move their posts between draft and review; anything that
+	// puts a post in front of readers or takes it away is up to editors
+	editorial := status.Editorial() && existingPost.Status.Editorial()
+	if (req.Featured != existingPost.Featured || ((statusChanged || dateChanged) && !editorial)) && !auth.CanPublishPost(claims) {
+		writeError(w, r, http.StatusForbidden, "Only editors can publish, schedule, archive or feature posts")
+		return
+	}
+	rewritten := h.RequireApproval && rewrites(existingPost, req)
+	if !checkStatusChange(w, r, existingPost.Status, status, publishedAt, dateChanged) ||
+		!h.checkApproval(w, r, existingPost, existingPost.Status, status, rewritten) {
+		return
+	}
+	// A scheduled post that is rewritten comes off the schedule until the
+	// new version has been approved
+	if rewritten && status == models.StatusScheduled {
+		status = models.StatusInReview
+	}
+	// Other saves keep the approval, unless they take the post down
+	carryApproval := h.RequireApproval && !rewritten && (needsApproval(status) || !needsApproval(existingPost.Status))
+	previousVersion := existingPost.Version
+
+	category, ok := h.findPostCategory(w, r, req.Category)
+	if !ok {
 		return
 	}
 
+	baseline := models.NewPostRevision(existingPost, nil)
+
 	// Update fields
 	existingPost.Title = req.Title
 	existingPost.Content = req.Content
-	existingPost.AuthorName = req.AuthorName
-	existingPost.Tags = strings.Join(req.Tags, ",")
-	existingPost.Category = req.Category
+	if req.ContentFormat != "" {
+		existingPost.ContentFormat = req.ContentFormat
+	}
+	if req.AuthorName != "" {
+		existingPost.AuthorName = req.AuthorName
+	}
+	existingPost.SetCategory(category)
 	existingPost.Featured = req.Featured
-	existingPost.Published = req.Published
+	existingPost.PublishedAt = publishedAt
+	existingPost.SetStatus(status, time.Now())
+
+	// Slugs only change when the client asks for it; the old one keeps redirecting
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := claimNextVersion(tx, existingPost); err != nil {
+			return err
+		}
 
-	if err := h.db.Save(&existingPost).Error; err != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+		if req.Slug != "" && req.Slug != existingPost.Slug {
+			newSlug, err := h.slugs.Rename(tx, existingPost.ID, existingPost.Slug, req.Slug)
+			if err != nil {
+				return err
+			}
+			existingPost.Slug = newSlug
+		}
+
+		tags, err := repository.FindOrCreateTags(tx, req.Tags)
+		if err != nil {
+			return err
+		}
+		existingPost.SetTags(tags)
+		if err := tx.Omit("Tags").Save(existingPost).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(existingPost).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+		if carryApproval {
+			if err := keepApproval(tx, existingPost, previousVersion); err != nil {
+				return err
+			}
+		}
+		return recordRevision(tx, existingPost, claims.UserID, &baseline)
+	})
+	if err != nil {
+		if errors.Is(err, errVersionConflict) {
+			writeVersionConflict(w, r)
+			return
+		}
+		if errors.Is(err, slug.ErrSlugTaken) || slug.IsUniqueViolation(err) {
+			writeError(w, r, http.StatusConflict, "Blog post with this slug already exists")
+			return
+		}
+		writeDatabaseError(w, r, err)
 		return
 	}
 
-	response := existingPost.ToResponse(true)
+	w.Header().Set("ETag", existingPost.ETag())
+	response := models.NewPostDetailResponse(existingPost)
 	json.NewEncoder(w).Encode(response)
 }
 
-// DeletePost handles DELETE /api/posts/{slug}
+// DeletePost handles DELETE /api/posts/{slug}. Authors may delete their own posts.
 func (h *BlogHandler) DeletePost(w http.ResponseWriter, r *http.Request) {
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
 	vars := mux.Vars(r)
 	slug := vars["slug"]
 
-	result := h.db.Where("slug = ?", slug).Delete(&models.BlogPost{})
-	if result.Error != nil {
-		http.Error(w, "Database error", http.StatusInternalServerError)
+	var post models.BlogPost
+	if err := h.db.Where("slug = ?", slug).First(&post).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusNotFound, "Blog post not found")
+			return
+		}
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	if !auth.CanDeletePost(claims, &post) {
+		writeError(w, r, http.StatusForbidden, "You are not allowed to delete this post")
 		return
 	}
 
+	if !checkIfMatch(w, r, &post) {
+		return
+	}
+
+	// Only delete the version the client saw, in case it changed since it was loaded
+	result := h.db.Where("version = ?", post.Version).Delete(&post)
+	if result.Error != nil {
+		writeDatabaseError(w, r, result.Error)
+		return
+	}
 	if result.RowsAffected == 0 {
-		http.Error(w, "Blog post not found", http.StatusNotFound)
+		writeVersionConflict(w, r)
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// findPostCategory looks up the category a post is being filed under by name
+// or slug. An empty value means no category.
+func (h *BlogHandler) findPostCategory(w http.ResponseWriter, r *http.Request, value string) (*models.Category, bool) {
+	if strings.TrimSpace(value) == "" {
+		return nil, true
+	}
+
+	var category models.Category
+	if err := h.db.Where("slug = ?", models.CategorySlug(value)).First(&category).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusBadRequest, "Unknown category")
+			return nil, false
+		}
+		writeDatabaseError(w, r, err)
+		return nil, false
+	}
+	return &category, true
+}
+
+// requireClaims returns the caller's token claims. Routes using it must be
+// wrapped in auth.TokenService.RequireAuth; otherwise the request is rejected.
+func requireClaims(w http.ResponseWriter, r *http.Request) (*auth.Claims, bool) {
+	claims, ok := auth.ClaimsFromContext(r.Context())
+	if !ok {
+		writeError(w, r, http.StatusUnauthorized, "Authentication required")
+		return nil, false
+	}
+	return claims, true
+}
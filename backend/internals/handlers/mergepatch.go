@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+
+	"blogapp/internals/models"
+)
+
+// mergePatchMediaType is the content type of a JSON Merge Patch (RFC 7396)
+const mergePatchMediaType = "application/merge-patch+json"
+
+// isMergePatch reports whether the request body is declared as a merge patch.
+// Plain application/json is accepted too, since many clients cannot set
+// anything else.
+func isMergePatch(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == mergePatchMediaType || mediaType == "application/json"
+}
+
+// applyMergePatch applies patch to the JSON form of req and decodes the result
+func applyMergePatch(req models.CreateBlogPostRequest, patch interface{}) (models.CreateBlogPostRequest, error) {
+	var patched models.CreateBlogPostRequest
+
+	current, err := json.Marshal(req)
+	if err != nil {
+		return patched, err
+	}
+
+	var document interface{}
+	if err := json.Unmarshal(current, &document); err != nil {
+		return patched, err
+	}
+
+	merged, err := json.Marshal(mergePatch(document, patch))
+	if err != nil {
+		return patched, err
+	}
+
+	err = json.Unmarshal(merged, &patched)
+	return patched, err
+}
+
+// mergePatch implements the MergePatch function of RFC 7396, section 2
+func mergePatch(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{})
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = mergePatch(targetObject[name], value)
+	}
+	return targetObject
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"blogapp/internals/models"
+)
+
+func patchedPost() models.BlogPost {
+	published := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+	post := models.BlogPost{
+		Slug:          "getting-started",
+		Title:         "Getting Started",
+		Content:       "<p>Original content</p>",
+		ContentFormat: models.FormatHTML,
+		AuthorName:    "Sarah Johnson",
+		Featured:      true,
+		Published:     true,
+		Status:        models.StatusPublished,
+		PublishedAt:   &published,
+	}
+	post.SetTags(models.NewTags([]string{"WCAG", "testing"}))
+	post.SetCategory(&models.Category{ID: 3, Name: "Accessibility", Slug: "accessibility"})
+	return post
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	post := patchedPost()
+	original := post.ToRequest()
+
+	tests := []struct {
+		name  string
+		patch string
+		want  func(req *models.CreateBlogPostRequest)
+	}{
+		{
+			name:  "title only leaves everything else",
+			patch: `{"title": "A better title"}`,
+			want:  func(req *models.CreateBlogPostRequest) { req.Title = "A better title" },
+		},
+		{
+			name:  "empty patch changes nothing",
+			patch: `{}`,
+			want:  func(req *models.CreateBlogPostRequest) {},
+		},
+		{
+			name:  "null tags clears them",
+			patch: `{"tags": null}`,
+			want:  func(req *models.CreateBlogPostRequest) { req.Tags = nil },
+		},
+		{
+			name:  "arrays are replaced, not merged",
+			patch: `{"tags": ["ARIA"]}`,
+			want:  func(req *models.CreateBlogPostRequest) { req.Tags = []string{"ARIA"} },
+		},
+		{
+			name:  "false is a value, not a removal",
+			patch: `{"featured": false, "category": null}`,
+			want: func(req *models.CreateBlogPostRequest) {
+				req.Featured = false
+				req.Category = ""
+			},
+		},
+		{
+			name:  "null published_at is dropped; savePost keeps the current date",
+			patch: `{"published_at": null}`,
+			want:  func(req *models.CreateBlogPostRequest) { req.PublishedAt = nil },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch interface{}
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatal(err)
+			}
+
+			want := post.ToRequest()
+			tt.want(&want)
+
+			got, err := applyMergePatch(post.ToRequest(), patch)
+			if err != nil {
+				t.Fatalf("applyMergePatch: %v", err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got  %+v\nwant %+v", got, want)
+			}
+		})
+	}
+
+	if !reflect.DeepEqual(post.ToRequest(), original) {
+		t.Error("applyMergePatch modified the post it was given")
+	}
+}
+
+func TestApplyMergePatchRejectsWrongTypes(t *testing.T) {
+	post := patchedPost()
+	var patch interface{}
+	if err := json.Unmarshal([]byte(`{"tags": {"name": "WCAG"}}`), &patch); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := applyMergePatch(post.ToRequest(), patch); err == nil {
+		t.Error("an object in place of the tags array was accepted")
+	}
+}
+
+// The examples from RFC 7396, appendix A, among them nested objects and
+// objects replaced by other values
+func TestMergePatch(t *testing.T) {
+	tests := []struct{ target, patch, want string }{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"a":1,"e":null}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{`{"a":{"b":"c"}}`, `{"a":"flat"}`, `{"a":"flat"}`},
+		{`{"a":"flat"}`, `{"a":{"b":"c","d":null}}`, `{"a":{"b":"c"}}`},
+	}
+
+	for _, tt := range tests {
+		var target, patch, want interface{}
+		for _, doc := range []struct {
+			src  string
+			dest *interface{}
+		}{{tt.target, &target}, {tt.patch, &patch}, {tt.want, &want}} {
+			if err := json.Unmarshal([]byte(doc.src), doc.dest); err != nil {
+				t.Fatalf("%s: %v", doc.src, err)
+			}
+		}
+
+		if got := mergePatch(target, patch); !reflect.DeepEqual(got, want) {
+			t.Errorf("mergePatch(%s, %s) = %v, want %s", tt.target, tt.patch, got, tt.want)
+		}
+	}
+}
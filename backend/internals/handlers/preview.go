@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"gorm.io/gorm"
+
+	"blogapp/internals/models"
+)
+
+// CreatePreview handles POST /api/posts/{slug}/preview. Anyone who may edit
+// the post gets a link that shows it, whatever its status, without signing in.
+func (h *BlogHandler) CreatePreview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	post, ok := h.findEditablePost(w, r, claims)
+	if !ok {
+		return
+	}
+
+	token, expiresAt := h.Previews.IssuePreviewToken(post.Slug)
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.PreviewResponse{
+		APIVersion: models.APIVersion,
+		Token:      token,
+		URL:        "/api/posts/" + url.PathEscape(post.Slug) + "?preview=" + url.QueryEscape(token),
+		ExpiresAt:  expiresAt,
+	})
+}
+
+// previewPost answers GET /api/posts/{slug}?preview=<token> with the post in
+// whatever state it is in. Responses are never cached or indexed, since the
+// post may not be public.
+func (h *BlogHandler) previewPost(w http.ResponseWriter, r *http.Request, slug, token string) {
+	w.Header().Set("Cache-Control", "private, no-store")
+	w.Header().Set("X-Robots-Tag", "noindex")
+
+	if h.Previews == nil || !h.Previews.VerifyPreviewToken(slug, token) {
+		writeError(w, r, http.StatusForbidden, "Invalid or expired preview token")
+		return
+	}
+
+	var post models.BlogPost
+	if err := h.db.Preload("Tags").Preload("Category").Where("slug = ?", slug).First(&post).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusNotFound, "Blog post not found")
+			return
+		}
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.NewPostDetailResponse(&post))
+}
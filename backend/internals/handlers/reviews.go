@@ -0,0 +1,523 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"blogapp/internals/auth"
+	"blogapp/internals/models"
+)
+
+// RequestReview handles POST /api/posts/{slug}/reviews. Anyone who may edit
+// the post can ask for a review, optionally naming the reviewer; a draft
+// moves to in_review. A post has at most one pending review at a time.
+func (h *BlogHandler) RequestReview(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	post, ok := h.findEditablePost(w, r, claims)
+	if !ok {
+		return
+	}
+
+	var req models.CreateReviewRequest
+	if err := decodeOptionalBody(r, &req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	var pending int64
+	if err := h.db.Model(&models.Review{}).Where("post_id = ? AND state = ?", post.ID, models.ReviewPending).Count(&pending).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+	if pending > 0 {
+		writeError(w, r, http.StatusConflict, "This post already has a pending review")
+		return
+	}
+
+	if req.ReviewerID != nil && !h.checkReviewer(w, r, claims, *req.ReviewerID) {
+		return
+	}
+
+	review := models.Review{
+		PostID:        post.ID,
+		RequestedByID: &claims.UserID,
+		ReviewerID:    req.ReviewerID,
+		State:         models.ReviewPending,
+	}
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if post.Status == models.StatusDraft {
+			if err := moveToStatus(tx, post, models.StatusInReview); err != nil {
+				return err
+			}
+		}
+		review.PostVersion = post.Version
+		if err := tx.Create(&review).Error; err != nil {
+			return err
+		}
+		return addReviewComment(tx, &review, claims.UserID, nil, req.Comment)
+	})
+	if err != nil {
+		if errors.Is(err, errVersionConflict) {
+			writeVersionConflict(w, r)
+			return
+		}
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	h.writeReview(w, r, review.ID)
+}
+
+// ListReviews handles GET /api/posts/{slug}/reviews, newest first, each with
+// its threaded discussion
+func (h *BlogHandler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	post, ok := h.findEditablePost(w, r, claims)
+	if !ok {
+		return
+	}
+
+	h.writeReviews(w, r, h.db.Where("post_id = ?", post.ID))
+}
+
+// GetMyReviews handles GET /api/reviews: the pending reviews waiting for the
+// caller. Reviewers also see pending reviews nobody has been assigned yet.
+func (h *BlogHandler) GetMyReviews(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	query := h.db.Where("state = ?", models.ReviewPending)
+	if auth.CanReviewPosts(claims) {
+		query = query.Where("reviewer_id = ? OR (reviewer_id IS NULL AND (requested_by_id IS NULL OR requested_by_id <> ?))", claims.UserID, claims.UserID)
+	} else {
+		query = query.Where("reviewer_id = ?", claims.UserID)
+	}
+
+	h.writeReviews(w, r, query)
+}
+
+// AssignReviewer handles PUT /api/posts/{slug}/reviews/{id}/reviewer. Editors
+// and whoever requested the review may (re)assign a pending review.
+func (h *BlogHandler) AssignReviewer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	post, ok := h.findEditablePost(w, r, claims)
+	if !ok {
+		return
+	}
+
+	review, ok := h.findPendingReview(w, r, post)
+	if !ok {
+		return
+	}
+
+	requestedByCaller := review.RequestedByID != nil && *review.RequestedByID == claims.UserID
+	if !requestedByCaller && !auth.CanReviewPosts(claims) {
+		writeError(w, r, http.StatusForbidden, "You are not allowed to assign this review")
+		return
+	}
+
+	var req models.AssignReviewerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	if review.RequestedByID != nil && *review.RequestedByID == req.ReviewerID {
+		writeError(w, r, http.StatusBadRequest, "A review cannot be assigned to the person who requested it")
+		return
+	}
+	if !h.checkReviewer(w, r, claims, req.ReviewerID) {
+		return
+	}
+
+	if err := h.db.Model(review).Update("reviewer_id", req.ReviewerID).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	h.writeReview(w, r, review.ID)
+}
+
+// ApproveReview handles POST /api/posts/{slug}/reviews/{id}/approve
+func (h *BlogHandler) ApproveReview(w http.ResponseWriter, r *http.Request) {
+	h.decideReview(w, r, models.ReviewApproved)
+}
+
+// RequestChanges handles POST /api/posts/{slug}/reviews/{id}/request-changes.
+// A post still in review goes back to draft.
+func (h *BlogHandler) RequestChanges(w http.ResponseWriter, r *http.Request) {
+	h.decideReview(w, r, models.ReviewChangesRequested)
+}
+
+// decideReview closes a pending review with state. Only editors may decide,
+// never on their own request, and only the assigned reviewer once there is
+// one. An unassigned review is assigned to whoever decides it.
+func (h *BlogHandler) decideReview(w http.ResponseWriter, r *http.Request, state models.ReviewState) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	post, ok := h.findEditablePost(w, r, claims)
+	if !ok {
+		return
+	}
+
+	review, ok := h.findPendingReview(w, r, post)
+	if !ok {
+		return
+	}
+
+	switch {
+	case !auth.CanReviewPosts(claims):
+		writeError(w, r, http.StatusForbidden, "Only editors can review posts")
+		return
+	case review.RequestedByID != nil && *review.RequestedByID == claims.UserID:
+		writeError(w, r, http.StatusForbidden, "You cannot review your own request")
+		return
+	case review.ReviewerID != nil && *review.ReviewerID != claims.UserID:
+		writeError(w, r, http.StatusForbidden, "This review is assigned to someone else")
+		return
+	}
+
+	var req models.ReviewDecisionRequest
+	if err := decodeOptionalBody(r, &req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	now := time.Now()
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		// Only one decision wins if two reviewers answer at once
+		result := tx.Model(&models.Review{}).
+			Where("id = ? AND state = ?", review.ID, models.ReviewPending).
+			Updates(map[string]interface{}{"state": state, "reviewer_id": claims.UserID, "decided_at": now, "post_version": post.Version})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errReviewDecided
+		}
+
+		if state == models.ReviewChangesRequested && post.Status == models.StatusInReview {
+			if err := moveToStatus(tx, post, models.StatusDraft); err != nil {
+				return err
+			}
+		}
+		return addReviewComment(tx, review, claims.UserID, nil, req.Comment)
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, errReviewDecided):
+			writeError(w, r, http.StatusConflict, "This review has already been decided")
+		case errors.Is(err, errVersionConflict):
+			writeVersionConflict(w, r)
+		default:
+			writeDatabaseError(w, r, err)
+		}
+		return
+	}
+
+	h.writeReview(w, r, review.ID)
+}
+
+// AddReviewComment handles POST /api/posts/{slug}/reviews/{id}/comments.
+// Setting parent_id replies to an earlier comment on the same review.
+func (h *BlogHandler) AddReviewComment(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	post, ok := h.findEditablePost(w, r, claims)
+	if !ok {
+		return
+	}
+
+	review, ok := h.findReview(w, r, post)
+	if !ok {
+		return
+	}
+
+	var req models.ReviewCommentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	if req.ParentID != nil {
+		var parents int64
+		if err := h.db.Model(&models.ReviewComment{}).Where("id = ? AND review_id = ?", *req.ParentID, review.ID).Count(&parents).Error; err != nil {
+			writeDatabaseError(w, r, err)
+			return
+		}
+		if parents == 0 {
+			writeError(w, r, http.StatusBadRequest, "Unknown parent comment")
+			return
+		}
+	}
+
+	if err := addReviewComment(h.db, review, claims.UserID, req.ParentID, req.Body); err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	h.writeReview(w, r, review.ID)
+}
+
+// errReviewDecided is returned when a review stopped being pending while it was being decided
+var errReviewDecided = errors.New("review already decided")
+
+// checkApproval enforces the approval policy: with RequireApproval set, a post
+// may only be published or scheduled once a review approved its current
+// version, and only if the save doing so does not rewrite it. post is nil for
+// posts that are being created and so cannot have one yet.
+func (h *BlogHandler) checkApproval(w http.ResponseWriter, r *http.Request, post *models.BlogPost, from, to models.PostStatus, rewritten bool) bool {
+	if !h.RequireApproval || !needsApproval(to) || from == to {
+		return true
+	}
+
+	var approved int64
+	if post != nil && !rewritten {
+		if err := h.db.Model(&models.Review{}).
+			Where("post_id = ? AND state = ? AND post_version = ?", post.ID, models.ReviewApproved, post.Version).
+			Count(&approved).Error; err != nil {
+			writeDatabaseError(w, r, err)
+			return false
+		}
+	}
+	if approved == 0 {
+		writeError(w, r, http.StatusConflict, "This post needs an approved review before it can be published")
+		return false
+	}
+	return true
+}
+
+// needsApproval reports whether a post in status has to have been approved
+// when RequireApproval is set
+func needsApproval(status models.PostStatus) bool {
+	return status == models.StatusPublished || status == models.StatusScheduled
+}
+
+// rewrites reports whether saving req over post changes what a reviewer signed
+// off: its title, its tags or its content as readers see it
+func rewrites(post *models.BlogPost, req *models.CreateBlogPostRequest) bool {
+	if req.Title != post.Title {
+		return true
+	}
+
+	tags := make(map[string]bool, len(post.Tags))
+	for _, tag := range post.Tags {
+		tags[tag.Slug] = true
+	}
+	requested := models.NewTags(req.Tags)
+	if len(requested) != len(tags) {
+		return true
+	}
+	for _, tag := range requested {
+		if !tags[tag.Slug] {
+			return true
+		}
+	}
+
+	// Compared as rendered, as clients send back the content they were
+	// given, heading anchors and all
+	edited := models.BlogPost{Content: req.Content, ContentFormat: post.ContentFormat}
+	if req.ContentFormat != "" {
+		edited.ContentFormat = req.ContentFormat
+	}
+	if err := edited.Render(); err != nil {
+		return true
+	}
+	return edited.ContentHTML != post.HTML()
+}
+
+// keepApproval moves the approval of post's previous version on to its
+// current one, after a save that neither rewrote the post nor took it down
+func keepApproval(tx *gorm.DB, post *models.BlogPost, previousVersion uint) error {
+	return tx.Model(&models.Review{}).
+		Where("post_id = ? AND state = ? AND post_version = ?", post.ID, models.ReviewApproved, previousVersion).
+		Update("post_version", post.Version).Error
+}
+
+// checkReviewer makes sure reviewerID names a user who can review and is not the caller
+func (h *BlogHandler) checkReviewer(w http.ResponseWriter, r *http.Request, claims *auth.Claims, reviewerID uint) bool {
+	if reviewerID == claims.UserID {
+		writeError(w, r, http.StatusBadRequest, "You cannot review your own post")
+		return false
+	}
+
+	var reviewer models.User
+	if err := h.db.First(&reviewer, reviewerID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusBadRequest, "Unknown reviewer")
+			return false
+		}
+		writeDatabaseError(w, r, err)
+		return false
+	}
+
+	if !auth.IsReviewer(&reviewer) {
+		writeError(w, r, http.StatusBadRequest, "Reviewers must be editors or admins")
+		return false
+	}
+	return true
+}
+
+// findReview loads the review named by the {id} route variable, which must belong to post
+func (h *BlogHandler) findReview(w http.ResponseWriter, r *http.Request, post *models.BlogPost) (*models.Review, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "Invalid review id")
+		return nil, false
+	}
+
+	var review models.Review
+	if err := h.db.Where("id = ? AND post_id = ?", id, post.ID).First(&review).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusNotFound, "Review not found")
+			return nil, false
+		}
+		writeDatabaseError(w, r, err)
+		return nil, false
+	}
+	return &review, true
+}
+
+// findPendingReview is findReview for actions that need the review to still be open
+func (h *BlogHandler) findPendingReview(w http.ResponseWriter, r *http.Request, post *models.BlogPost) (*models.Review, bool) {
+	review, ok := h.findReview(w, r, post)
+	if !ok {
+		return nil, false
+	}
+	if review.State != models.ReviewPending {
+		writeError(w, r, http.StatusConflict, "This review has already been decided")
+		return nil, false
+	}
+	return review, true
+}
+
+// writeReview responds with the review with the given id and its discussion
+func (h *BlogHandler) writeReview(w http.ResponseWriter, r *http.Request, id uint) {
+	var review models.Review
+	if err := preloadReview(h.db).First(&review, id).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.ReviewDetailResponse{
+		APIVersion: models.APIVersion,
+		Review:     review.ToResponse(),
+	})
+}
+
+// writeReviews responds with the reviews matched by query, newest first
+func (h *BlogHandler) writeReviews(w http.ResponseWriter, r *http.Request, query *gorm.DB) {
+	var reviews []models.Review
+	if err := preloadReview(query).Order("created_at DESC, id DESC").Find(&reviews).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	response := models.ReviewListResponse{
+		APIVersion: models.APIVersion,
+		Reviews:    make([]models.ReviewResponse, 0, len(reviews)),
+	}
+	for i := range reviews {
+		response.Reviews = append(response.Reviews, reviews[i].ToResponse())
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func preloadReview(query *gorm.DB) *gorm.DB {
+	return query.
+		Preload("RequestedBy").
+		Preload("Reviewer").
+		Preload("Comments", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") })
+}
+
+// addReviewComment adds body to review as the user authorID; an empty body adds nothing
+func addReviewComment(tx *gorm.DB, review *models.Review, authorID uint, parentID *uint, body string) error {
+	if body == "" {
+		return nil
+	}
+
+	var author models.User
+	if err := tx.First(&author, authorID).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&models.ReviewComment{
+		ReviewID:   review.ID,
+		ParentID:   parentID,
+		AuthorID:   &author.ID,
+		AuthorName: author.Name,
+		Body:       body,
+	}).Error
+}
+
+// moveToStatus changes only post's status, as part of the review workflow
+func moveToStatus(tx *gorm.DB, post *models.BlogPost, status models.PostStatus) error {
+	if err := claimNextVersion(tx, post); err != nil {
+		return err
+	}
+	post.SetStatus(status, time.Now())
+	return tx.Model(post).UpdateColumns(map[string]interface{}{"status": post.Status, "published": post.Published}).Error
+}
+
+// decodeOptionalBody decodes a JSON body into v, leaving v untouched when the
+// body is empty
+func decodeOptionalBody(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"blogapp/internals/auth"
+	"blogapp/internals/models"
+	"blogapp/internals/repository"
+)
+
+// reviewServer serves the post and review routes over a fresh SQLite
+// database, with approval required before publishing
+type reviewServer struct {
+	t      *testing.T
+	db     *gorm.DB
+	router *mux.Router
+	author string // Bearer tokens
+	editor string
+}
+
+func newReviewServer(t *testing.T) *reviewServer {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "blog.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetupJoinTable(&models.BlogPost{}, "Tags", &models.PostTag{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.BlogPost{}, &models.User{}, &models.SlugHistory{}, &models.Tag{}, &models.PostTag{},
+		&models.Category{}, &models.PostRevision{}, &models.Review{}, &models.ReviewComment{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := auth.NewTokenService(strings.Repeat("s", 32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &reviewServer{t: t, db: db, router: mux.NewRouter()}
+	for _, user := range []*models.User{
+		{Name: "Ada Author", Email: "author@example.com", Role: models.RoleAuthor},
+		{Name: "Eve Editor", Email: "editor@example.com", Role: models.RoleEditor},
+	} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatal(err)
+		}
+		token, _, err := tokens.IssueAccessToken(user)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if user.Role == models.RoleAuthor {
+			s.author = token
+		} else {
+			s.editor = token
+		}
+	}
+
+	h := NewBlogHandler(db, repository.NewGormPostRepository(db))
+	h.RequireApproval = true
+	api := s.router.PathPrefix("/api").Subrouter()
+	api.HandleFunc("/posts", tokens.RequireAuth(h.CreatePost)).Methods("POST")
+	api.HandleFunc("/posts/{slug}", tokens.RequireAuth(h.UpdatePost)).Methods("PUT")
+	api.HandleFunc("/posts/{slug}/reviews", tokens.RequireAuth(h.RequestReview)).Methods("POST")
+	api.HandleFunc("/posts/{slug}/reviews/{id:[0-9]+}/approve", tokens.RequireAuth(h.ApproveReview)).Methods("POST")
+	return s
+}
+
+// do sends body as token and decodes the JSON response into v, if given
+func (s *reviewServer) do(token, method, path string, body interface{}, v interface{}) int {
+	s.t.Helper()
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, strings.NewReader(string(data)))
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	s.router.ServeHTTP(rec, req)
+
+	if v != nil && rec.Code < 300 {
+		if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+			s.t.Fatalf("%s %s: %v", method, path, err)
+		}
+	}
+	return rec.Code
+}
+
+// save PUTs the post with the given title, content and status
+func (s *reviewServer) save(token, title, content string, status models.PostStatus, publishAt time.Time) (int, models.PostStatus) {
+	s.t.Helper()
+
+	var response models.PostDetailResponse
+	code := s.do(token, "PUT", "/api/posts/approved-post", map[string]interface{}{
+		"title": title, "content": content, "status": status, "published_at": publishAt, "tags": []string{"WCAG"},
+	}, &response)
+	return code, response.Post.Status
+}
+
+// scheduleApproved creates a post as the author, has the editor approve it
+// and schedule it for publishAt
+func (s *reviewServer) scheduleApproved(content string, publishAt time.Time) {
+	s.t.Helper()
+
+	if code := s.do(s.author, "POST", "/api/posts", map[string]interface{}{
+		"title": "Approved post", "content": content, "tags": []string{"WCAG"},
+	}, nil); code != http.StatusCreated {
+		s.t.Fatalf("create: %d", code)
+	}
+	if code := s.do(s.author, "POST", "/api/posts/approved-post/reviews", map[string]string{}, nil); code != http.StatusCreated {
+		s.t.Fatalf("request review: %d", code)
+	}
+	if code := s.do(s.editor, "POST", "/api/posts/approved-post/reviews/1/approve", map[string]string{}, nil); code != http.StatusOK {
+		s.t.Fatalf("approve: %d", code)
+	}
+	if code, status := s.save(s.editor, "Approved post", content, models.StatusScheduled, publishAt); code != http.StatusOK || status != models.StatusScheduled {
+		s.t.Fatalf("schedule: %d %s", code, status)
+	}
+}
+
+func (s *reviewServer) publishDue(at time.Time) int64 {
+	s.t.Helper()
+
+	published, err := repository.PublishDue(s.db, at, true)
+	if err != nil {
+		s.t.Fatal(err)
+	}
+	return published
+}
+
+var approvedContent = "<h2>Contrast</h2><p>" + strings.Repeat("Text needs a contrast ratio of at least 4.5:1. ", 4) + "</p>"
+
+func TestScheduledPostPublishesWithApproval(t *testing.T) {
+	s := newReviewServer(t)
+	publishAt := time.Now().Add(time.Hour).UTC()
+	s.scheduleApproved(approvedContent, publishAt)
+
+	// Moving the date is not a rewrite, and sending back the anchored
+	// content the API returned is not one either
+	later := publishAt.Add(time.Hour)
+	if code, status := s.save(s.editor, "Approved post", approvedContent, models.StatusScheduled, later); code != http.StatusOK || status != models.StatusScheduled {
+		t.Fatalf("reschedule: %d %s", code, status)
+	}
+	anchored := strings.Replace(approvedContent, "<h2>", `<h2 id="contrast">`, 1)
+	if code, status := s.save(s.author, "Approved post", anchored, models.StatusScheduled, later); code != http.StatusOK || status != models.StatusScheduled {
+		t.Fatalf("save unchanged: %d %s", code, status)
+	}
+
+	if published := s.publishDue(later.Add(time.Minute)); published != 1 {
+		t.Errorf("PublishDue published %d posts, want 1", published)
+	}
+}
+
+// An author may edit their scheduled post, but the rewrite must not go out
+// on the strength of the approval the previous version had
+func TestRewritingScheduledPostNeedsNewApproval(t *testing.T) {
+	s := newReviewServer(t)
+	publishAt := time.Now().Add(time.Hour).UTC()
+	s.scheduleApproved(approvedContent, publishAt)
+
+	rewritten := approvedContent + "<p>Added after the review.</p>"
+	code, status := s.save(s.author, "Approved post", rewritten, models.StatusScheduled, publishAt)
+	if code != http.StatusOK {
+		t.Fatalf("rewrite: %d", code)
+	}
+	if status != models.StatusInReview {
+		t.Errorf("status after rewrite = %s, want %s", status, models.StatusInReview)
+	}
+
+	// Scheduling it again, or publishing it, needs a new approval
+	for _, to := range []models.PostStatus{models.StatusScheduled, models.StatusPublished} {
+		if code, _ := s.save(s.editor, "Approved post", rewritten, to, publishAt); code != http.StatusConflict {
+			t.Errorf("%s after rewrite: %d, want %d", to, code, http.StatusConflict)
+		}
+	}
+
+	if published := s.publishDue(publishAt.Add(time.Minute)); published != 0 {
+		t.Errorf("PublishDue published %d posts, want 0", published)
+	}
+}
+
+// A post scheduled and then rewritten behind the handlers' back, as an
+// older server would have allowed, must not be published
+func TestPublishDueSkipsUnapprovedVersion(t *testing.T) {
+	s := newReviewServer(t)
+	publishAt := time.Now().Add(time.Hour).UTC()
+	s.scheduleApproved(approvedContent, publishAt)
+
+	if err := s.db.Model(&models.BlogPost{}).Where("slug = ?", "approved-post").
+		UpdateColumn("version", gorm.Expr("version + 1")).Error; err != nil {
+		t.Fatal(err)
+	}
+	if published := s.publishDue(publishAt.Add(time.Minute)); published != 0 {
+		t.Errorf("PublishDue published %d posts, want 0", published)
+	}
+
+	var post models.BlogPost
+	if err := s.db.Where("slug = ?", "approved-post").First(&post).Error; err != nil {
+		t.Fatal(err)
+	}
+	if post.Status != models.StatusScheduled {
+		t.Errorf("status = %s, want %s", post.Status, models.StatusScheduled)
+	}
+	if post.Published {
+		t.Error("published = true, want false")
+	}
+}
@@ -0,0 +1,222 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"blogapp/internals/diff"
+	"blogapp/internals/models"
+)
+
+// ListRevisions handles GET /api/posts/{slug}/revisions, newest first.
+// Anyone who may edit the post may read its history.
+func (h *BlogHandler) ListRevisions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	post, ok := h.findEditablePost(w, r, claims)
+	if !ok {
+		return
+	}
+
+	var revisions []models.PostRevision
+	if err := h.db.Where("post_id = ?", post.ID).Order("version DESC").Find(&revisions).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	response := models.RevisionListResponse{
+		APIVersion: models.APIVersion,
+		Revisions:  make([]models.PostRevisionResponse, 0, len(revisions)),
+	}
+	for i := range revisions {
+		response.Revisions = append(response.Revisions, revisions[i].ToResponse(false))
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetRevision handles GET /api/posts/{slug}/revisions/{id}
+func (h *BlogHandler) GetRevision(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	post, ok := h.findEditablePost(w, r, claims)
+	if !ok {
+		return
+	}
+
+	revision, ok := h.findRevision(w, r, post, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.RevisionDetailResponse{
+		APIVersion: models.APIVersion,
+		Revision:   revision.ToResponse(true),
+	})
+}
+
+// DiffRevision handles GET /api/posts/{slug}/revisions/{id}/diff. The content
+// is compared line by line with the revision given by ?from, or with the one
+// before it when from is omitted.
+func (h *BlogHandler) DiffRevision(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	post, ok := h.findEditablePost(w, r, claims)
+	if !ok {
+		return
+	}
+
+	to, ok := h.findRevision(w, r, post, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	var from *models.PostRevision
+	if fromID := r.URL.Query().Get("from"); fromID != "" {
+		if from, ok = h.findRevision(w, r, post, fromID); !ok {
+			return
+		}
+	} else {
+		// The first revision has nothing before it and is diffed against empty content
+		var previous []models.PostRevision
+		if err := h.db.Where("post_id = ? AND version < ?", post.ID, to.Version).Order("version DESC").Limit(1).Find(&previous).Error; err != nil {
+			writeDatabaseError(w, r, err)
+			return
+		}
+		if len(previous) > 0 {
+			from = &previous[0]
+		}
+	}
+
+	response := models.RevisionDiffResponse{
+		APIVersion: models.APIVersion,
+		To:         to.ToResponse(false),
+	}
+	oldContent := ""
+	if from != nil {
+		fromResponse := from.ToResponse(false)
+		response.From = &fromResponse
+		response.TitleChanged = from.Title != to.Title
+		oldContent = from.Content
+	}
+
+	lines, err := diff.Lines(oldContent, to.Content)
+	if err != nil {
+		writeError(w, r, http.StatusUnprocessableEntity, "The revisions differ in too many lines to compare")
+		return
+	}
+	response.Lines = lines
+	for _, line := range response.Lines {
+		switch line.Op {
+		case diff.Insert:
+			response.Added++
+		case diff.Delete:
+			response.Removed++
+		}
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
+// RestoreRevision handles POST /api/posts/{slug}/revisions/{id}/restore. The
+// revision's title, content and tags are saved as a new revision, so the
+// restore itself can be undone. If-Match is honoured as for PUT.
+func (h *BlogHandler) RestoreRevision(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	post, ok := h.findEditablePost(w, r, claims)
+	if !ok || !checkIfMatch(w, r, post) {
+		return
+	}
+
+	revision, ok := h.findRevision(w, r, post, mux.Vars(r)["id"])
+	if !ok {
+		return
+	}
+
+	req := post.ToRequest()
+	req.Title = revision.Title
+	req.Content = revision.Content
+	req.ContentFormat = revision.ContentFormat
+	req.Tags = revision.TagList()
+
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	h.savePost(w, r, claims, post, &req)
+}
+
+// findRevision loads one of post's revisions by id
+func (h *BlogHandler) findRevision(w http.ResponseWriter, r *http.Request, post *models.BlogPost, rawID string) (*models.PostRevision, bool) {
+	id, err := strconv.ParseUint(rawID, 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "Invalid revision id")
+		return nil, false
+	}
+
+	var revision models.PostRevision
+	if err := h.db.Where("id = ? AND post_id = ?", id, post.ID).First(&revision).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusNotFound, "Revision not found")
+			return nil, false
+		}
+		writeDatabaseError(w, r, err)
+		return nil, false
+	}
+	return &revision, true
+}
+
+// recordRevision snapshots post as saved by the user behind editorID. Posts
+// written before revisions were tracked first get their previous state
+// recorded as baseline, so the first edit can still be undone.
+func recordRevision(tx *gorm.DB, post *models.BlogPost, editorID uint, baseline *models.PostRevision) error {
+	if baseline != nil {
+		var count int64
+		if err := tx.Model(&models.PostRevision{}).Where("post_id = ?", post.ID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			if err := tx.Create(baseline).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	var editor *models.User
+	var user models.User
+	err := tx.First(&user, editorID).Error
+	switch {
+	case err == nil:
+		editor = &user
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return err
+	}
+
+	revision := models.NewPostRevision(post, editor)
+	return tx.Create(&revision).Error
+}
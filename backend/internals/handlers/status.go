@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"blogapp/internals/models"
+)
+
+// checkStatusChange enforces the workflow when a post moves from one status
+// to another; from is empty for new posts. Scheduling needs a publish date
+// in the future.
+func checkStatusChange(w http.ResponseWriter, r *http.Request, from, to models.PostStatus, publishedAt *time.Time, dateChanged bool) bool {
+	if from != "" && !from.CanTransitionTo(to) {
+		writeError(w, r, http.StatusConflict, fmt.Sprintf("A %s post cannot be moved to %s", from, to))
+		return false
+	}
+
+	if to == models.StatusScheduled && (from != to || dateChanged) {
+		if publishedAt == nil || !publishedAt.After(time.Now()) {
+			writeError(w, r, http.StatusUnprocessableEntity, "Scheduled posts need a published_at in the future")
+			return false
+		}
+	}
+	return true
+}
+
+// sameTime reports whether two optional timestamps are the same instant
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
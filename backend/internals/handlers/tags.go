@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"blogapp/internals/models"
+	"blogapp/internals/repository"
+)
+
+// GetTags handles GET /api/tags. Each tag comes with the number of published
+// posts using it, most used first.
+func (h *BlogHandler) GetTags(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	tags, err := h.posts.ListTags(r.Context())
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	response := models.TagListResponse{APIVersion: models.APIVersion, Tags: tags}
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetTagPosts handles GET /api/tags/{tag}/posts. {tag} may be the tag's slug
+// or its name; page and limit work as on GET /api/posts.
+func (h *BlogHandler) GetTagPosts(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	tag, err := h.posts.GetTag(r.Context(), models.TagSlug(mux.Vars(r)["tag"]))
+	if err != nil {
+		if errors.Is(err, repository.ErrTagNotFound) {
+			writeError(w, r, http.StatusNotFound, "Tag not found")
+			return
+		}
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	q, err := parsePostQuery(r, "search")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	q.Tag = tag.Slug
+
+	posts, totalCount, err := h.posts.ListPublished(r.Context(), q)
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	response := models.TagPostsResponse{
+		PostListResponse: models.NewPostListResponse(posts, q.Page, q.Limit, totalCount),
+		Tag:              *tag,
+	}
+	json.NewEncoder(w).Encode(response)
+}
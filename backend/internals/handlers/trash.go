@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"blogapp/internals/auth"
+	"blogapp/internals/models"
+)
+
+// GetTrash handles GET /api/admin/trash: deleted posts, most recently deleted
+// first, paginated like GET /api/posts. Only editors and admins see the trash.
+func (h *BlogHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	if !auth.CanManageTrash(claims) {
+		writeError(w, r, http.StatusForbidden, "Only editors can view the trash")
+		return
+	}
+
+	q, err := parsePostQuery(r, "search")
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	trashed := h.db.Unscoped().Model(&models.BlogPost{}).Where("deleted_at IS NOT NULL")
+
+	var totalCount int64
+	if err := trashed.Session(&gorm.Session{}).Count(&totalCount).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	var posts []models.BlogPost
+	if err := trashed.Preload("Tags").Preload("Category").
+		Order("deleted_at DESC").
+		Offset(q.Offset()).Limit(q.Limit).
+		Find(&posts).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	response := models.NewPostListResponse(posts, q.Page, q.Limit, totalCount)
+	json.NewEncoder(w).Encode(response)
+}
+
+// RestorePost handles POST /api/posts/{slug}/restore, taking a post back out
+// of the trash. Whoever could delete the post may restore it.
+func (h *BlogHandler) RestorePost(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return
+	}
+
+	var post models.BlogPost
+	if err := h.db.Unscoped().Preload("Tags").Preload("Category").
+		Where("slug = ? AND deleted_at IS NOT NULL", mux.Vars(r)["slug"]).
+		First(&post).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusNotFound, "No deleted post with this slug")
+			return
+		}
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	if !auth.CanDeletePost(claims, &post) {
+		writeError(w, r, http.StatusForbidden, "You are not allowed to restore this post")
+		return
+	}
+
+	if !checkIfMatch(w, r, &post) {
+		return
+	}
+
+	result := h.db.Unscoped().Model(&post).
+		Where("version = ?", post.Version).
+		Updates(map[string]interface{}{"deleted_at": nil, "version": gorm.Expr("version + 1")})
+	if result.Error != nil {
+		writeDatabaseError(w, r, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		writeVersionConflict(w, r)
+		return
+	}
+	post.DeletedAt = gorm.DeletedAt{}
+	post.Version++
+
+	w.Header().Set("ETag", post.ETag())
+	json.NewEncoder(w).Encode(models.NewPostDetailResponse(&post))
+}
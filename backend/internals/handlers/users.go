@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+
+	"blogapp/internals/auth"
+	"blogapp/internals/models"
+	"blogapp/internals/slug"
+)
+
+// UserHandler serves the admin-only user management endpoints
+type UserHandler struct {
+	db *gorm.DB
+}
+
+func NewUserHandler(db *gorm.DB) *UserHandler {
+	return &UserHandler{db: db}
+}
+
+// ListUsers handles GET /api/admin/users
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !h.authorize(w, r) {
+		return
+	}
+
+	var users []models.User
+	if err := h.db.Order("id").Find(&users).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	userResponses := make([]models.UserResponse, 0, len(users))
+	for i := range users {
+		userResponses = append(userResponses, users[i].ToResponse())
+	}
+
+	json.NewEncoder(w).Encode(models.UserListResponse{APIVersion: models.APIVersion, Users: userResponses})
+}
+
+// CreateUser handles POST /api/admin/users
+func (h *UserHandler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !h.authorize(w, r) {
+		return
+	}
+
+	var req models.CreateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = models.RoleReader
+	}
+
+	if !validateRequest(w, r, &req) {
+		return
+	}
+	if !req.Role.Valid() {
+		writeError(w, r, http.StatusBadRequest, "Unknown role")
+		return
+	}
+
+	user := models.User{Email: req.Email, Name: req.Name, Role: req.Role}
+	if err := user.SetPassword(req.Password); err != nil {
+		writeError(w, r, http.StatusInternalServerError, "Failed to hash password")
+		return
+	}
+
+	if err := h.db.Create(&user).Error; err != nil {
+		if slug.IsUniqueViolation(err) {
+			writeError(w, r, http.StatusConflict, "A user with this email already exists")
+			return
+		}
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.UserDetailResponse{APIVersion: models.APIVersion, User: user.ToResponse()})
+}
+
+// UpdateUser handles PUT /api/admin/users/{id}. Changing a user's password or
+// role signs them out everywhere by revoking their refresh tokens.
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if !h.authorize(w, r) {
+		return
+	}
+
+	user, ok := h.findUser(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.UpdateUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeInvalidJSON(w, r)
+		return
+	}
+
+	if !validateRequest(w, r, &req) {
+		return
+	}
+
+	if req.Name != nil {
+		user.Name = *req.Name
+	}
+	revoke := false
+	if req.Role != nil {
+		if !req.Role.Valid() {
+			writeError(w, r, http.StatusBadRequest, "Unknown role")
+			return
+		}
+		revoke = *req.Role != user.Role
+		user.Role = *req.Role
+	}
+	if req.Password != nil {
+		if err := user.SetPassword(*req.Password); err != nil {
+			writeError(w, r, http.StatusInternalServerError, "Failed to hash password")
+			return
+		}
+		revoke = true
+	}
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		if !revoke {
+			return nil
+		}
+		return tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Update("revoked_at", time.Now()).Error
+	})
+	if err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	json.NewEncoder(w).Encode(models.UserDetailResponse{APIVersion: models.APIVersion, User: user.ToResponse()})
+}
+
+// DeleteUser handles DELETE /api/admin/users/{id}. Posts by the user are kept
+// with their author_id cleared.
+func (h *UserHandler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if !h.authorize(w, r) {
+		return
+	}
+
+	user, ok := h.findUser(w, r)
+	if !ok {
+		return
+	}
+
+	claims, _ := auth.ClaimsFromContext(r.Context())
+	if user.ID == claims.UserID {
+		writeError(w, r, http.StatusConflict, "You cannot delete your own account")
+		return
+	}
+
+	if err := h.db.Delete(user).Error; err != nil {
+		writeDatabaseError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorize checks that the caller is an admin
+func (h *UserHandler) authorize(w http.ResponseWriter, r *http.Request) bool {
+	claims, ok := requireClaims(w, r)
+	if !ok {
+		return false
+	}
+
+	if !auth.CanManageUsers(claims) {
+		writeError(w, r, http.StatusForbidden, "Only admins can manage users")
+		return false
+	}
+	return true
+}
+
+// findUser loads the user named by the {id} route variable
+func (h *UserHandler) findUser(w http.ResponseWriter, r *http.Request) (*models.User, bool) {
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "Invalid user id")
+		return nil, false
+	}
+
+	var user models.User
+	if err := h.db.First(&user, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeError(w, r, http.StatusNotFound, "User not found")
+			return nil, false
+		}
+		writeDatabaseError(w, r, err)
+		return nil, false
+	}
+	return &user, true
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"blogapp/internals/validation"
+)
+
+// validateRequest checks req against its validate tags. On failure it writes a
+// 422 whose details list every invalid field and returns false.
+func validateRequest(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	err := validation.Struct(req)
+	if err == nil {
+		return true
+	}
+
+	var fieldErrs validation.Errors
+	if !errors.As(err, &fieldErrs) {
+		writeError(w, r, http.StatusBadRequest, "Invalid request")
+		return false
+	}
+
+	apiErr := NewAPIError(http.StatusUnprocessableEntity, "Validation failed")
+	apiErr.Details = fieldErrs
+	writeAPIError(w, r, apiErr)
+	return false
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"blogapp/internals/validation"
+)
+
+func TestCreatePostValidation(t *testing.T) {
+	s := newReviewServer(t)
+
+	content := strings.Repeat("Accessible content. ", 5)
+	tests := []struct {
+		name string
+		body map[string]interface{}
+		want []validation.FieldError // nil when the post is created
+	}{
+		{"valid", map[string]interface{}{"title": "Valid title", "content": content, "tags": []string{"WCAG"}}, nil},
+		{"valid markdown", map[string]interface{}{"title": "Markdown post", "content": content, "content_format": "markdown"}, nil},
+		{"title too short", map[string]interface{}{"title": "Tiny", "content": content},
+			[]validation.FieldError{{Field: "title", Rule: "min", Message: "title must be at least 5 characters"}}},
+		{"content too short", map[string]interface{}{"title": "Valid title", "content": "Too short"},
+			[]validation.FieldError{{Field: "content", Rule: "min", Message: "content must be at least 100 characters"}}},
+		{"unknown status", map[string]interface{}{"title": "Valid title", "content": content, "status": "live"},
+			[]validation.FieldError{{Field: "status", Rule: "oneof", Message: "status must be one of: draft, in_review, scheduled, published, archived"}}},
+		{"unknown content format", map[string]interface{}{"title": "Valid title", "content": content, "content_format": "rtf"},
+			[]validation.FieldError{{Field: "content_format", Rule: "oneof", Message: "content_format must be one of: html, markdown"}}},
+		{"tag too long", map[string]interface{}{"title": "Valid title", "content": content, "tags": []string{"WCAG", strings.Repeat("t", 101)}},
+			[]validation.FieldError{{Field: "tags[1]", Rule: "max", Message: "tags[1] must be at most 100 characters"}}},
+		{"every field reported", map[string]interface{}{"title": "Tiny", "content": "Too short", "status": "live"},
+			[]validation.FieldError{
+				{Field: "title", Rule: "min", Message: "title must be at least 5 characters"},
+				{Field: "content", Rule: "min", Message: "content must be at least 100 characters"},
+				{Field: "status", Rule: "oneof", Message: "status must be one of: draft, in_review, scheduled, published, archived"},
+			}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := httptest.NewRequest("POST", "/api/posts", strings.NewReader(string(data)))
+			req.Header.Set("Authorization", "Bearer "+s.author)
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			s.router.ServeHTTP(rec, req)
+
+			if tt.want == nil {
+				if rec.Code != http.StatusCreated {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusCreated, rec.Body)
+				}
+				return
+			}
+
+			if rec.Code != http.StatusUnprocessableEntity {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusUnprocessableEntity, rec.Body)
+			}
+			var response ErrorResponse
+			if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+			if response.Error.Code != CodeValidationFailed {
+				t.Errorf("code = %q, want %q", response.Error.Code, CodeValidationFailed)
+			}
+			if !reflect.DeepEqual(response.Error.Details, tt.want) {
+				t.Errorf("details = %+v\nwant %+v", response.Error.Details, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"blogapp/internals/slug"
+)
+
+// Category groups posts. Categories nest through ParentID, and filtering on a
+// category also matches posts in any of its descendants.
+type Category struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"not null;size:100" validate:"required"`
+	Slug        string    `json:"slug" gorm:"uniqueIndex;not null;size:100"`
+	Description string    `json:"description" gorm:"type:text"`
+	ParentID    *uint     `json:"parent_id" gorm:"index"`
+	Parent      *Category `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	CreatedAt   time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// CategoryResponse represents a category in API responses. PostCount includes
+// the published posts of every descendant.
+type CategoryResponse struct {
+	ID          uint   `json:"id"`
+	Name        string `json:"name"`
+	Slug        string `json:"slug"`
+	Description string `json:"description"`
+	ParentID    *uint  `json:"parent_id"`
+	PostCount   int64  `json:"post_count"`
+}
+
+// ToResponse converts Category to CategoryResponse
+func (c *Category) ToResponse(postCount int64) CategoryResponse {
+	return CategoryResponse{
+		ID:          c.ID,
+		Name:        c.Name,
+		Slug:        c.Slug,
+		Description: c.Description,
+		ParentID:    c.ParentID,
+		PostCount:   postCount,
+	}
+}
+
+// CategoryListResponse is the contract for GET /api/categories
+type CategoryListResponse struct {
+	APIVersion string             `json:"api_version"`
+	Categories []CategoryResponse `json:"categories"`
+}
+
+// CategoryDetailResponse is the contract for endpoints returning one category
+type CategoryDetailResponse struct {
+	APIVersion string           `json:"api_version"`
+	Category   CategoryResponse `json:"category"`
+}
+
+// CategoryRequest represents the request structure for creating or replacing a category
+type CategoryRequest struct {
+	Name        string `json:"name" validate:"required,max=100"`
+	Slug        string `json:"slug" validate:"max=100"` // Optional; derived from the name when empty
+	Description string `json:"description"`
+	ParentID    *uint  `json:"parent_id"`
+}
+
+// CategorySlug returns the slug identifying the category called name, derived
+// the same way as a tag's
+func CategorySlug(name string) string {
+	return slug.Key(name)
+}
@@ -0,0 +1,21 @@
+package models
+
+import "testing"
+
+// Categories share the tag slug rules, so names in other scripts or that only
+// differ in punctuation are different categories
+func TestCategorySlugKeepsNamesApart(t *testing.T) {
+	names := []string{"日本語", "中文", "한국어", "C", "C#", "C++", "!!!", "???"}
+
+	seen := make(map[string]string)
+	for _, name := range names {
+		slug := CategorySlug(name)
+		if other, ok := seen[slug]; ok {
+			t.Errorf("CategorySlug(%q) = CategorySlug(%q) = %q", name, other, slug)
+		}
+		seen[slug] = name
+		if got := CategorySlug(slug); got != slug {
+			t.Errorf("CategorySlug(%q) = %q, want it unchanged", slug, got)
+		}
+	}
+}
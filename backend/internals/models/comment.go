@@ -0,0 +1,186 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// CommentStatus is where a reader comment stands in moderation
+type CommentStatus string
+
+const (
+	// CommentPending is waiting in the moderation queue
+	CommentPending CommentStatus = "pending"
+	// CommentApproved is shown under the post
+	CommentApproved CommentStatus = "approved"
+	// CommentRejected was turned down by a moderator
+	CommentRejected CommentStatus = "rejected"
+	// CommentSpam was marked as spam by a moderator
+	CommentSpam CommentStatus = "spam"
+)
+
+// Valid reports whether s is one of the known comment statuses
+func (s CommentStatus) Valid() bool {
+	switch s {
+	case CommentPending, CommentApproved, CommentRejected, CommentSpam:
+		return true
+	}
+	return false
+}
+
+// Comment is a reader's comment on a post. Replies point at the comment they
+// answer through ParentID. Comments start out pending and are only shown once
+// a moderator approves them.
+type Comment struct {
+	ID          uint          `json:"id" gorm:"primaryKey"`
+	PostID      uint          `json:"post_id" gorm:"not null;index"`
+	Post        BlogPost      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	ParentID    *uint         `json:"parent_id" gorm:"index"`
+	Parent      *Comment      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	AuthorName  string        `json:"author_name" gorm:"not null;size:100"`
+	AuthorEmail string        `json:"-" gorm:"not null;size:255"`     // Only shown to moderators
+	Body        string        `json:"body" gorm:"type:text;not null"` // Sanitised HTML
+	Status      CommentStatus `json:"status" gorm:"not null;size:20;default:pending;index"`
+	ModeratedAt *time.Time    `json:"moderated_at"`
+	CreatedAt   time.Time     `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time     `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// CommentResponse is a published comment with its replies nested under it.
+// The author's email address is never included.
+type CommentResponse struct {
+	ID         uint              `json:"id"`
+	ParentID   *uint             `json:"parent_id"`
+	AuthorName string            `json:"author_name"`
+	Body       string            `json:"body"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Replies    []CommentResponse `json:"replies"`
+}
+
+// NewCommentThread nests comments under the comments they reply to, oldest
+// first at every level. A reply whose parent is not in comments, because a
+// moderator has since rejected it, is listed at the top level instead.
+func NewCommentThread(comments []Comment) []CommentResponse {
+	present := make(map[uint]bool, len(comments))
+	for _, comment := range comments {
+		present[comment.ID] = true
+	}
+
+	children := make(map[uint][]Comment)
+	var roots []Comment
+	for _, comment := range comments {
+		if comment.ParentID == nil || !present[*comment.ParentID] {
+			roots = append(roots, comment)
+			continue
+		}
+		children[*comment.ParentID] = append(children[*comment.ParentID], comment)
+	}
+
+	var build func(level []Comment) []CommentResponse
+	build = func(level []Comment) []CommentResponse {
+		responses := make([]CommentResponse, 0, len(level))
+		for _, comment := range level {
+			responses = append(responses, CommentResponse{
+				ID:         comment.ID,
+				ParentID:   comment.ParentID,
+				AuthorName: comment.AuthorName,
+				Body:       comment.Body,
+				CreatedAt:  comment.CreatedAt,
+				Replies:    build(children[comment.ID]),
+			})
+		}
+		return responses
+	}
+	return build(roots)
+}
+
+// CommentThreadResponse is the contract for GET /api/posts/{slug}/comments
+type CommentThreadResponse struct {
+	APIVersion string            `json:"api_version"`
+	Count      int               `json:"count"`
+	Comments   []CommentResponse `json:"comments"`
+}
+
+// ModerationCommentResponse represents a comment in the moderation queue
+type ModerationCommentResponse struct {
+	ID          uint          `json:"id"`
+	PostID      uint          `json:"post_id"`
+	PostSlug    string        `json:"post_slug"`
+	PostTitle   string        `json:"post_title"`
+	ParentID    *uint         `json:"parent_id"`
+	AuthorName  string        `json:"author_name"`
+	AuthorEmail string        `json:"author_email"`
+	Body        string        `json:"body"`
+	Status      CommentStatus `json:"status"`
+	ModeratedAt *time.Time    `json:"moderated_at"`
+	CreatedAt   time.Time     `json:"created_at"`
+}
+
+// ToModerationResponse converts Comment to ModerationCommentResponse. Post
+// must be loaded for its slug and title to be filled in.
+func (c *Comment) ToModerationResponse() ModerationCommentResponse {
+	return ModerationCommentResponse{
+		ID:          c.ID,
+		PostID:      c.PostID,
+		PostSlug:    c.Post.Slug,
+		PostTitle:   c.Post.Title,
+		ParentID:    c.ParentID,
+		AuthorName:  c.AuthorName,
+		AuthorEmail: c.AuthorEmail,
+		Body:        c.Body,
+		Status:      c.Status,
+		ModeratedAt: c.ModeratedAt,
+		CreatedAt:   c.CreatedAt,
+	}
+}
+
+// ModerationListResponse is the contract for GET /api/admin/comments
+type ModerationListResponse struct {
+	APIVersion    string                      `json:"api_version"`
+	Status        CommentStatus               `json:"status"`
+	Comments      []ModerationCommentResponse `json:"comments"`
+	CurrentPage   int                         `json:"current_page"`
+	Limit         int                         `json:"limit"`
+	TotalPages    int                         `json:"total_pages"`
+	TotalComments int64                       `json:"total_comments"`
+}
+
+// NewModerationListResponse builds the moderation queue response for one page of comments
+func NewModerationListResponse(status CommentStatus, comments []Comment, page, limit int, total int64) ModerationListResponse {
+	responses := make([]ModerationCommentResponse, 0, len(comments))
+	for i := range comments {
+		responses = append(responses, comments[i].ToModerationResponse())
+	}
+
+	return ModerationListResponse{
+		APIVersion:    APIVersion,
+		Status:        status,
+		Comments:      responses,
+		CurrentPage:   page,
+		Limit:         limit,
+		TotalPages:    int(math.Ceil(float64(total) / float64(limit))),
+		TotalComments: total,
+	}
+}
+
+// ModerationDetailResponse is the contract for the moderation actions
+type ModerationDetailResponse struct {
+	APIVersion string                    `json:"api_version"`
+	Comment    ModerationCommentResponse `json:"comment"`
+}
+
+// CreateCommentRequest is a reader's new comment, optionally a reply
+type CreateCommentRequest struct {
+	AuthorName  string `json:"author_name" validate:"required,max=100"`
+	AuthorEmail string `json:"author_email" validate:"required,email,max=255"`
+	Body        string `json:"body" validate:"required,max=5000"`
+	ParentID    *uint  `json:"parent_id"`
+}
+
+// CreateCommentResponse is returned once a comment has been queued for moderation
+type CreateCommentResponse struct {
+	APIVersion string        `json:"api_version"`
+	ID         uint          `json:"id"`
+	Status     CommentStatus `json:"status"`
+	Message    string        `json:"message"`
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// ExcerptLength is the most runes a generated excerpt may have, not counting
+// the trailing "..." added when a sentence had to be cut. Set it at startup;
+// the excerpt column holds 500 characters.
+var ExcerptLength = 200
+
+// excerptEllipsis marks an excerpt that ends mid-sentence
+const excerptEllipsis = "..."
+
+// blockElements start a new line of text, so their words are kept apart
+// when the markup is removed
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true, "br": true,
+	"dd": true, "details": true, "div": true, "dl": true, "dt": true, "figcaption": true,
+	"figure": true, "footer": true, "h1": true, "h2": true, "h3": true, "h4": true,
+	"h5": true, "h6": true, "header": true, "hr": true, "li": true, "ol": true, "p": true,
+	"pre": true, "section": true, "summary": true, "table": true, "td": true, "th": true,
+	"tr": true, "ul": true,
+}
+
+// generateExcerpt returns the start of content as plain text of at most
+// ExcerptLength runes. It ends at the last full sentence that fits, else at
+// the last whole word followed by "...".
+func generateExcerpt(content string) string {
+	return truncateText(plainText(content), ExcerptLength)
+}
+
+// plainText returns the text of an HTML fragment with entities decoded and
+// runs of whitespace collapsed to single spaces
+func plainText(content string) string {
+	var b strings.Builder
+	skip := 0 // Depth inside elements whose text is not shown
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(b.String()), " ")
+		case html.TextToken:
+			if skip == 0 {
+				b.Write(z.Text())
+			}
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			name, _ := z.TagName()
+			switch string(name) {
+			case "script", "style", "template":
+				if tt == html.StartTagToken {
+					skip++
+				} else if skip > 0 {
+					skip--
+				}
+			default:
+				if blockElements[string(name)] {
+					b.WriteByte(' ')
+				}
+			}
+		}
+	}
+}
+
+// truncateText shortens text to at most limit runes, preferring to end after
+// a sentence in the second half of the budget, then at a space, and only cuts
+// inside a word when there is no space to cut at, as in Chinese or Japanese
+func truncateText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+
+	// A sentence ends in terminal punctuation followed by a space, the end
+	// of the budget, or CJK punctuation which is not followed by one
+	for i := limit - 1; i >= limit/2; i-- {
+		if !isSentenceEnd(runes[i]) {
+			continue
+		}
+		if isCJKSentenceEnd(runes[i]) || unicode.IsSpace(runes[i+1]) {
+			return string(runes[:i+1])
+		}
+	}
+
+	cut := limit
+	for i := limit; i >= limit/2; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	// Never split a character from the marks, joiners or modifiers that
+	// combine with it, such as the parts of an emoji sequence
+	for cut > 0 && (joinsPrevious(runes[cut]) || runes[cut-1] == zeroWidthJoiner) {
+		cut--
+	}
+
+	return strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(",;:、，", r)
+	}) + excerptEllipsis
+}
+
+const zeroWidthJoiner = '\u200d'
+
+func isSentenceEnd(r rune) bool {
+	return r == '.' || r == '!' || r == '?' || isCJKSentenceEnd(r)
+}
+
+func isCJKSentenceEnd(r rune) bool {
+	return r == '。' || r == '！' || r == '？'
+}
+
+// joinsPrevious reports whether r is displayed as part of the character before it
+func joinsPrevious(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r == zeroWidthJoiner ||
+		(r >= '\ufe00' && r <= '\ufe0f') || // Variation selectors
+		(r >= 0x1f3fb && r <= 0x1f3ff) // Skin tone modifiers
+}
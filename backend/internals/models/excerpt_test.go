@@ -0,0 +1,78 @@
+package models
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{"short text is kept", "Hello.", 10, "Hello."},
+		{"ends after a sentence", "One two. Three four five.", 12, "One two."},
+		{"ends at a word", "One. Two three four.", 12, "One. Two..."},
+		{"trailing comma dropped", "Alpha, beta gamma", 8, "Alpha..."},
+		{"Japanese sentence", "日本語の文章です。次の文です。", 10, "日本語の文章です。"},
+		{"Chinese sentence", "这是第一句话！这是第二句话。", 9, "这是第一句话！"},
+		{"CJK without punctuation", "日本語日本語日本語日本語", 5, "日本語日本..."},
+		{"CJK comma dropped", "中文、中文中文中文中文中文", 3, "中文..."},
+		{"emoji", strings.Repeat("😀", 10), 4, "😀😀😀😀..."},
+		{"emoji joined by ZWJ", "ab👨‍👩‍👧cd", 4, "ab..."},
+		{"emoji skin tone", "ab👍🏽cd", 3, "ab..."},
+		{"emoji variation selector", "ab❤️cd", 3, "ab..."},
+		{"combining accent", "cafés", 4, "caf..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateText(tt.text, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncateText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+			if !utf8.ValidString(got) {
+				t.Errorf("truncateText(%q, %d) = %q is not valid UTF-8", tt.text, tt.limit, got)
+			}
+		})
+	}
+}
+
+func TestGenerateExcerpt(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{"markup removed", "<h2>Title</h2><p>Fish &amp; chips</p>", "Title Fish & chips"},
+		{"script skipped", "<p>Before</p><script>alert(1)</script><p>after.</p>", "Before after."},
+		{"Japanese", "<p>日本語の文章です。</p>", "日本語の文章です。"},
+		{"long Japanese", "<p>" + strings.Repeat("日本語の文章です。", 30) + "</p>", strings.Repeat("日本語の文章です。", 22)},
+		{"long emoji", "<p>" + strings.Repeat("🎉", 300) + "</p>", strings.Repeat("🎉", 200) + excerptEllipsis},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := generateExcerpt(tt.content)
+			if got != tt.want {
+				t.Errorf("generateExcerpt(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+			if !utf8.ValidString(got) || utf8.RuneCountInString(got) > ExcerptLength+len(excerptEllipsis) {
+				t.Errorf("generateExcerpt(%q) = %q is invalid or too long", tt.content, got)
+			}
+		})
+	}
+}
+
+func TestRenderRegeneratesExcerpt(t *testing.T) {
+	post := &BlogPost{Content: "<p>Rewritten.</p>", Excerpt: "The old excerpt"}
+	if err := post.Render(); err != nil {
+		t.Fatal(err)
+	}
+	if post.Excerpt != "Rewritten." {
+		t.Errorf("Excerpt = %q, want %q", post.Excerpt, "Rewritten.")
+	}
+}
@@ -0,0 +1,65 @@
+package models
+
+import "blogapp/internals/render"
+
+// ContentFormat is the language a post's content is written in
+type ContentFormat string
+
+const (
+	// FormatHTML content is sanitised when the post is saved
+	FormatHTML ContentFormat = "html"
+	// FormatMarkdown content is rendered to HTML, then sanitised
+	FormatMarkdown ContentFormat = "markdown"
+)
+
+// Valid reports whether f is one of the known content formats
+func (f ContentFormat) Valid() bool {
+	return f == FormatHTML || f == FormatMarkdown
+}
+
+// RenderContent turns content written in format into HTML that is safe to
+// hand to a browser
+func RenderContent(format ContentFormat, content string) (string, error) {
+	html := content
+	if format == FormatMarkdown {
+		var err error
+		if html, err = render.Markdown(content); err != nil {
+			return "", err
+		}
+	}
+	return SanitizeContent(html), nil
+}
+
+// Render sanitises HTML content and refreshes ContentHTML from it, along with
+// the excerpt, table of contents, word count and reading time. Markdown is kept as
+// written; only its rendering is sanitised. Saving a post calls it.
+func (bp *BlogPost) Render() error {
+	if bp.ContentFormat == "" {
+		bp.ContentFormat = FormatHTML
+	}
+	if bp.ContentFormat == FormatHTML {
+		bp.Content = SanitizeContent(bp.Content)
+	}
+	html, err := RenderContent(bp.ContentFormat, bp.Content)
+	if err != nil {
+		return err
+	}
+	bp.ContentHTML, bp.TableOfContents = addHeadingAnchors(html)
+	bp.Excerpt = generateExcerpt(bp.ContentHTML)
+	bp.WordCount = countWords(plainText(bp.ContentHTML))
+	bp.ReadingTimeMinutes = readingTime(bp.WordCount)
+	return nil
+}
+
+// HTML returns the post's content as readers see it. Posts that were never
+// saved or rendered are rendered on the fly.
+func (bp *BlogPost) HTML() string {
+	if bp.ContentHTML != "" || bp.Content == "" {
+		return bp.ContentHTML
+	}
+	rendered := *bp
+	if err := rendered.Render(); err != nil {
+		return ""
+	}
+	return rendered.ContentHTML
+}
@@ -1,176 +1,182 @@
 package models
 
 import (
-	"regexp"
+	"fmt"
 	"strings"
 	"time"
 
 	"gorm.io/gorm"
+
+	"blogapp/internals/slug"
 )
 
 // BlogPost represents a blog post in the database
 type BlogPost struct {
-	ID          uint       `json:"id" gorm:"primaryKey"`
-	Title       string     `json:"title" gorm:"not null;size:255" validate:"required,min=5,max=255"`
-	Slug        string     `json:"slug" gorm:"unique;not null;size:255" validate:"required"`
-	Content     string     `json:"content" gorm:"not null;type:text" validate:"required,min=100"`
-	Excerpt     string     `json:"excerpt" gorm:"size:500"`
-	AuthorName  string     `json:"author_name" gorm:"not null;size:100" validate:"required"`
-	Tags        string     `json:"tags" gorm:"size:500"` // Comma-separated tags
-	Category    string     `json:"category" gorm:"size:100"`
-	Featured    bool       `json:"featured" gorm:"default:false"`
-	Published   bool       `json:"published" gorm:"default:true"`
-	PublishedAt *time.Time `json:"published_at"`
-	CreatedAt   time.Time  `json:"created_at" gorm:"autoCreateTime"`
-	UpdatedAt   time.Time  `json:"updated_at" gorm:"autoUpdateTime"`
+	ID            uint           `json:"id" gorm:"primaryKey"`
+	Title         string         `json:"title" gorm:"not null;size:255" validate:"required,min=5,max=255"`
+	Slug          string         `json:"slug" gorm:"unique;not null;size:255" validate:"required"`
+	Content       string         `json:"content" gorm:"not null;type:text" validate:"required,min=100"`
+	ContentFormat ContentFormat  `json:"content_format" gorm:"not null;size:20;default:html"`
+	ContentHTML   string         `json:"-" gorm:"column:content_html;type:text"` // Content rendered and sanitised for readers
+	Excerpt       string         `json:"excerpt" gorm:"size:500"`
+	AuthorName    string         `json:"author_name" gorm:"not null;size:100" validate:"required"`
+	AuthorID      *uint          `json:"author_id" gorm:"index"`
+	Author        *User          `json:"-" gorm:"foreignKey:AuthorID;constraint:OnDelete:SET NULL"`
+	Tags          []Tag          `json:"tags" gorm:"many2many:post_tags;joinForeignKey:PostID;joinReferences:TagID;constraint:OnDelete:CASCADE"`
+	TagNames      string         `json:"-" gorm:"column:tags;size:500"` // Comma-separated copy of Tags for full-text search
+	CategoryID    *uint          `json:"category_id" gorm:"index"`
+	Category      *Category      `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	Featured      bool           `json:"featured" gorm:"default:false"`
+	Published     bool           `json:"published" gorm:"default:false"` // Mirrors Status == StatusPublished
+	Status        PostStatus     `json:"status" gorm:"not null;size:20;default:draft;index"`
+	PublishedAt   *time.Time     `json:"published_at"`
+	Version       uint           `json:"-" gorm:"not null;default:1"`
+	CreatedAt     time.Time      `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
+	DeletedAt     gorm.DeletedAt `json:"deleted_at" gorm:"index"` // Set while the post is in the trash
+
+	// Worked out from ContentHTML whenever the content is saved
+	WordCount          int             `json:"word_count" gorm:"not null;default:0"`
+	ReadingTimeMinutes int             `json:"reading_time_minutes" gorm:"not null;default:0"`
+	TableOfContents    TableOfContents `json:"table_of_contents" gorm:"type:text"` // The h2 and h3 headings
+
+	// Filled in by full-text searches only; never stored
+	SearchRank     float64 `json:"-" gorm:"->;-:migration"`
+	SearchHeadline string  `json:"-" gorm:"->;-:migration"`
+
+	// Number of approved comments, filled in by the repository; never stored
+	CommentCount int64 `json:"-" gorm:"-"`
 }
 
-// BeforeCreate hook to generate slug and excerpt
+// BeforeCreate hook to generate slug and excerpt and to sanitise the content
 func (bp *BlogPost) BeforeCreate(tx *gorm.DB) error {
+	// Handlers allocate unique slugs through slug.Service; this only covers
+	// rows created directly, which still hit the unique constraint on collision
 	if bp.Slug == "" {
-		bp.Slug = generateSlug(bp.Title)
+		bp.Slug = slug.Make(bp.Title)
+	}
+
+	if err := bp.Render(); err != nil {
+		return err
 	}
 
-	if bp.Excerpt == "" && bp.Content != "" {
-		bp.Excerpt = generateExcerpt(bp.Content)
+	if bp.Version == 0 {
+		bp.Version = 1
 	}
 
-	if bp.Published && bp.PublishedAt == nil {
-		now := time.Now()
-		bp.PublishedAt = &now
+	// Rows created without a status, such as fixtures, follow the published flag
+	if bp.Status == "" {
+		bp.Status = statusFromPublished(bp.Published)
 	}
+	bp.SetStatus(bp.Status, time.Now())
 
 	return nil
 }
 
-// BeforeUpdate hook
+// BeforeUpdate hook to sanitise and re-render the content and regenerate the
+// excerpt. Updates of single columns leave Content empty and skip it.
 func (bp *BlogPost) BeforeUpdate(tx *gorm.DB) error {
-	if bp.Excerpt == "" && bp.Content != "" {
-		bp.Excerpt = generateExcerpt(bp.Content)
+	if bp.Content == "" {
+		return nil
 	}
-	return nil
+
+	return bp.Render()
 }
 
-// BlogPostResponse represents the API response structure
-type BlogPostResponse struct {
-	ID          uint       `json:"id"`
-	Title       string     `json:"title"`
-	Slug        string     `json:"slug"`
-	Content     string     `json:"content,omitempty"`
-	Excerpt     string     `json:"excerpt"`
-	AuthorName  string     `json:"author_name"`
-	Tags        []string   `json:"tags"`
-	Category    string     `json:"category"`
-	Featured    bool       `json:"featured"`
-	Published   bool       `json:"published"`
-	PublishedAt *time.Time `json:"published_at"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+// SetTags replaces the post's tags and the search copy of their names. The
+// post_tags rows are written when the post is created, or by replacing the
+// Tags association on update.
+func (bp *BlogPost) SetTags(tags []Tag) {
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name)
+	}
+	bp.Tags = tags
+	bp.TagNames = strings.Join(names, ",")
 }
 
-// ToResponse converts BlogPost to BlogPostResponse
-func (bp *BlogPost) ToResponse(includeContent bool) BlogPostResponse {
-	response := BlogPostResponse{
-		ID:          bp.ID,
-		Title:       bp.Title,
-		Slug:        bp.Slug,
-		Excerpt:     bp.Excerpt,
-		AuthorName:  bp.AuthorName,
-		Tags:        parseTags(bp.Tags),
-		Category:    bp.Category,
-		Featured:    bp.Featured,
-		Published:   bp.Published,
-		PublishedAt: bp.PublishedAt,
-		CreatedAt:   bp.CreatedAt,
-		UpdatedAt:   bp.UpdatedAt,
+// ETag returns the entity tag of the post's current version
+func (bp *BlogPost) ETag() string {
+	return fmt.Sprintf(`"%d-%d"`, bp.ID, bp.Version)
+}
+
+// SetCategory files the post under category, or under none when it is nil
+func (bp *BlogPost) SetCategory(category *Category) {
+	bp.Category = category
+	bp.CategoryID = nil
+	if category != nil {
+		bp.CategoryID = &category.ID
 	}
+}
 
-	if includeContent {
-		response.Content = bp.Content
+// TagList returns the names of the post's tags
+func (bp *BlogPost) TagList() []string {
+	names := make([]string, 0, len(bp.Tags))
+	for _, tag := range bp.Tags {
+		names = append(names, tag.Name)
 	}
+	return names
+}
 
-	return response
+// SlugHistory records a slug a post used to have, so old links keep resolving
+type SlugHistory struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	PostID    uint      `json:"post_id" gorm:"not null;index"`
+	Post      BlogPost  `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Slug      string    `json:"slug" gorm:"uniqueIndex;not null;size:255"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
 }
 
-// PaginatedResponse represents paginated blog posts response
-type PaginatedResponse struct {
-	Posts       []BlogPostResponse `json:"posts"`
-	CurrentPage int                `json:"current_page"`
-	TotalPages  int                `json:"total_pages"`
-	TotalPosts  int64              `json:"total_posts"`
-	HasNext     bool               `json:"has_next"`
-	HasPrev     bool               `json:"has_prev"`
+// TableName keeps the table name singular to match the migration
+func (SlugHistory) TableName() string {
+	return "slug_history"
 }
 
 // CreateBlogPostRequest represents the request structure for creating a blog post
 type CreateBlogPostRequest struct {
-	Title      string   `json:"title" validate:"required,min=5,max=255"`
-	Content    string   `json:"content" validate:"required,min=100"`
-	AuthorName string   `json:"author_name" validate:"required"`
-	Tags       []string `json:"tags"`
-	Category   string   `json:"category"`
-	Featured   bool     `json:"featured"`
-	Published  bool     `json:"published"`
+	Slug          string        `json:"slug" validate:"max=255"` // Optional; on update a different value renames the post
+	Title         string        `json:"title" validate:"required,min=5,max=255"`
+	Content       string        `json:"content" validate:"required,min=100"`
+	ContentFormat ContentFormat `json:"content_format" validate:"omitempty,oneof=html markdown"` // Defaults to html; on update, to the current format
+	AuthorName    string        `json:"author_name" validate:"max=100"`                          // Defaults to the signed-in user's name
+	Tags          []string      `json:"tags" validate:"dive,max=100"`
+	Category      string        `json:"category" validate:"max=100"` // Name or slug of an existing category
+	Featured      bool          `json:"featured"`
+	Published     bool          `json:"published"` // Legacy; Status wins when both change
+	Status        PostStatus    `json:"status" validate:"omitempty,oneof=draft in_review scheduled published archived"`
+	PublishedAt   *time.Time    `json:"published_at"` // Required when scheduling; null keeps the current date
 }
 
 // ToBlogPost converts CreateBlogPostRequest to BlogPost
 func (req *CreateBlogPostRequest) ToBlogPost() BlogPost {
 	return BlogPost{
-		Title:      req.Title,
-		Content:    req.Content,
-		AuthorName: req.AuthorName,
-		Tags:       strings.Join(req.Tags, ","),
-		Category:   req.Category,
-		Featured:   req.Featured,
-		Published:  req.Published,
-	}
-}
-
-// Helper functions
-
-func generateSlug(title string) string {
-	// Convert to lowercase
-	slug := strings.ToLower(title)
-
-	// Replace non-alphanumeric characters with hyphens
-	reg := regexp.MustCompile("[^a-z0-9]+")
-	slug = reg.ReplaceAllString(slug, "-")
-
-	// Remove leading and trailing hyphens
-	slug = strings.Trim(slug, "-")
-
-	// Add timestamp to ensure uniqueness
-	timestamp := time.Now().Unix()
-	return slug + "-" + string(rune(timestamp%10000))
-}
-
-func generateExcerpt(content string) string {
-	// Remove HTML tags
-	reg := regexp.MustCompile("<[^>]*>")
-	plainText := reg.ReplaceAllString(content, "")
-
-	// Limit to 200 characters
-	if len(plainText) > 200 {
-		return plainText[:200] + "..."
+		Title:         req.Title,
+		Content:       req.Content,
+		ContentFormat: req.ContentFormat,
+		AuthorName:    req.AuthorName,
+		Featured:      req.Featured,
+		Status:        req.InitialStatus(),
+		PublishedAt:   req.PublishedAt,
 	}
-
-	return plainText
 }
 
-func parseTags(tagsString string) []string {
-	if tagsString == "" {
-		return []string{}
+// ToRequest returns the post's editable fields as a request, the document
+// PATCH applies merge patches to. Tags and Category must be loaded.
+func (bp *BlogPost) ToRequest() CreateBlogPostRequest {
+	req := CreateBlogPostRequest{
+		Slug:          bp.Slug,
+		Title:         bp.Title,
+		Content:       bp.Content,
+		ContentFormat: bp.ContentFormat,
+		AuthorName:    bp.AuthorName,
+		Tags:          bp.TagList(),
+		Featured:      bp.Featured,
+		Published:     bp.Published,
+		Status:        bp.Status,
+		PublishedAt:   bp.PublishedAt,
 	}
-
-	tags := strings.Split(tagsString, ",")
-	var cleanTags []string
-
-	for _, tag := range tags {
-		cleanTag := strings.TrimSpace(tag)
-		if cleanTag != "" {
-			cleanTags = append(cleanTags, cleanTag)
-		}
+	if bp.Category != nil {
+		req.Category = bp.Category.Slug
 	}
-
-	return cleanTags
+	return req
 }
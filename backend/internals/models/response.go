@@ -0,0 +1,190 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// APIVersion identifies the posts response contract. Bump it whenever a field
+// is renamed, removed or changes type; adding optional fields does not need a bump.
+const APIVersion = "1"
+
+// BlogPostResponse represents the API response structure
+type BlogPostResponse struct {
+	ID            uint          `json:"id"`
+	Title         string        `json:"title"`
+	Slug          string        `json:"slug"`
+	Content       string        `json:"content,omitempty"` // Rendered, sanitised HTML
+	ContentFormat ContentFormat `json:"content_format"`
+	ContentSource string        `json:"content_source,omitempty"` // The markdown as written, for markdown posts
+	Excerpt       string        `json:"excerpt"`
+	AuthorName    string        `json:"author_name"`
+	AuthorID      *uint         `json:"author_id"`
+	Tags          []string      `json:"tags"`
+	Category      string        `json:"category"`
+	CategoryID    *uint         `json:"category_id"`
+	CategorySlug  string        `json:"category_slug,omitempty"`
+	Featured      bool          `json:"featured"`
+	Published     bool          `json:"published"`
+	Status        PostStatus    `json:"status"`
+	PublishedAt   *time.Time    `json:"published_at"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+	DeletedAt     *time.Time    `json:"deleted_at,omitempty"`
+	CommentCount  int64         `json:"comment_count"`
+	Search        *SearchHit    `json:"search,omitempty"`
+
+	WordCount          int        `json:"word_count"`
+	ReadingTimeMinutes int        `json:"reading_time_minutes"`
+	TableOfContents    []TOCEntry `json:"table_of_contents,omitempty"` // With content only
+}
+
+// SearchHit describes how a post matched a full-text search
+type SearchHit struct {
+	Rank     float64 `json:"rank"`
+	Headline string  `json:"headline"` // Plain text with matches wrapped in <mark> tags
+}
+
+// ToResponse converts BlogPost to BlogPostResponse
+func (bp *BlogPost) ToResponse(includeContent bool) BlogPostResponse {
+	response := BlogPostResponse{
+		ID:            bp.ID,
+		Title:         bp.Title,
+		Slug:          bp.Slug,
+		ContentFormat: bp.ContentFormat,
+		Excerpt:       bp.Excerpt,
+		AuthorName:    bp.AuthorName,
+		AuthorID:      bp.AuthorID,
+		Tags:          bp.TagList(),
+		CategoryID:    bp.CategoryID,
+		Featured:      bp.Featured,
+		Published:     bp.Published,
+		Status:        bp.Status,
+		PublishedAt:   bp.PublishedAt,
+		CreatedAt:     bp.CreatedAt,
+		UpdatedAt:     bp.UpdatedAt,
+		CommentCount:  bp.CommentCount,
+
+		WordCount:          bp.WordCount,
+		ReadingTimeMinutes: bp.ReadingTimeMinutes,
+	}
+
+	if bp.Category != nil {
+		response.Category = bp.Category.Name
+		response.CategorySlug = bp.Category.Slug
+	}
+
+	if includeContent {
+		response.Content = bp.HTML()
+		response.TableOfContents = bp.TableOfContents
+		if bp.ContentFormat == FormatMarkdown {
+			response.ContentSource = bp.Content
+		}
+	}
+
+	if bp.DeletedAt.Valid {
+		deletedAt := bp.DeletedAt.Time
+		response.DeletedAt = &deletedAt
+	}
+
+	if bp.SearchHeadline != "" || bp.SearchRank != 0 {
+		response.Search = &SearchHit{Rank: bp.SearchRank, Headline: bp.SearchHeadline}
+	}
+
+	return response
+}
+
+// PostListResponse is the contract for every endpoint returning a page of posts
+type PostListResponse struct {
+	APIVersion  string             `json:"api_version"`
+	Posts       []BlogPostResponse `json:"posts"`
+	CurrentPage int                `json:"current_page"`
+	Limit       int                `json:"limit"`
+	TotalPages  int                `json:"total_pages"`
+	TotalPosts  int64              `json:"total_posts"`
+	HasNext     bool               `json:"has_next"`
+	HasPrev     bool               `json:"has_prev"`
+}
+
+// NewPostListResponse builds a list response for one page of posts. Content is
+// left out of list items; clients fetch it through the detail endpoint.
+func NewPostListResponse(posts []BlogPost, page, limit int, totalPosts int64) PostListResponse {
+	postResponses := make([]BlogPostResponse, 0, len(posts))
+	for i := range posts {
+		postResponses = append(postResponses, posts[i].ToResponse(false))
+	}
+
+	totalPages := int(math.Ceil(float64(totalPosts) / float64(limit)))
+
+	return PostListResponse{
+		APIVersion:  APIVersion,
+		Posts:       postResponses,
+		CurrentPage: page,
+		Limit:       limit,
+		TotalPages:  totalPages,
+		TotalPosts:  totalPosts,
+		HasNext:     page < totalPages,
+		HasPrev:     page > 1,
+	}
+}
+
+// PostDetailResponse is the contract for every endpoint returning a single post
+type PostDetailResponse struct {
+	APIVersion string           `json:"api_version"`
+	Post       BlogPostResponse `json:"post"`
+}
+
+// NewPostDetailResponse builds a detail response including the post content
+func NewPostDetailResponse(post *BlogPost) PostDetailResponse {
+	return PostDetailResponse{
+		APIVersion: APIVersion,
+		Post:       post.ToResponse(true),
+	}
+}
+
+// PostRedirectResponse is returned with a 301 when a post is requested by a
+// slug it no longer uses
+type PostRedirectResponse struct {
+	APIVersion string `json:"api_version"`
+	Redirect   string `json:"redirect"`
+	Slug       string `json:"slug"`
+}
+
+// NewPostRedirectResponse builds a redirect response pointing at location
+func NewPostRedirectResponse(slug, location string) PostRedirectResponse {
+	return PostRedirectResponse{
+		APIVersion: APIVersion,
+		Redirect:   location,
+		Slug:       slug,
+	}
+}
+
+// PreviewResponse is the contract for POST /api/posts/{slug}/preview
+type PreviewResponse struct {
+	APIVersion string    `json:"api_version"`
+	Token      string    `json:"token"`
+	URL        string    `json:"url"` // GET it to read the post without signing in
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// SearchResponse is the contract for GET /api/search: a page of ranked hits
+// in the PostListResponse shape, plus facet counts over all matches
+type SearchResponse struct {
+	PostListResponse
+	Query  string       `json:"query"`
+	Facets SearchFacets `json:"facets"`
+}
+
+// SearchFacets holds the number of matching posts per filter value
+type SearchFacets struct {
+	Categories []FacetCount `json:"categories"`
+	Tags       []FacetCount `json:"tags"`
+	Authors    []FacetCount `json:"authors"`
+	Years      []FacetCount `json:"years"`
+}
+
+// FacetCount is one value of a facet and how many posts have it
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
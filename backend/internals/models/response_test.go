@@ -0,0 +1,111 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The frontend reads these keys by name, so renaming one must fail here
+// before it breaks blog.service.js
+
+var postKeys = []string{
+	"id", "title", "slug", "content_format", "excerpt", "author_name", "author_id",
+	"tags", "category", "category_id", "category_slug", "featured", "published",
+	"status", "published_at", "created_at", "updated_at", "comment_count",
+	"word_count", "reading_time_minutes",
+}
+
+func contractPost() *BlogPost {
+	published := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+	post := &BlogPost{
+		ID:            1,
+		Title:         "Getting Started",
+		Slug:          "getting-started",
+		Content:       "## Why\n\nBecause.",
+		ContentFormat: FormatMarkdown,
+		Excerpt:       "Because.",
+		AuthorName:    "Sarah Johnson",
+		Featured:      true,
+		Published:     true,
+		Status:        StatusPublished,
+		PublishedAt:   &published,
+		CreatedAt:     published,
+		UpdatedAt:     published,
+		CommentCount:  2,
+	}
+	post.SetTags(NewTags([]string{"WCAG"}))
+	post.SetCategory(&Category{ID: 3, Name: "Accessibility", Slug: "accessibility"})
+	if err := post.Render(); err != nil {
+		panic(err)
+	}
+	return post
+}
+
+// keysOf marshals v and returns the keys of the JSON object at path
+func keysOf(t *testing.T, v interface{}, path ...string) []string {
+	t.Helper()
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(data, &object); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	for _, key := range path {
+		raw := object[key]
+		if strings.HasPrefix(string(raw), "[") {
+			var items []json.RawMessage
+			if err := json.Unmarshal(raw, &items); err != nil || len(items) == 0 {
+				t.Fatalf("%s: want a non-empty array, got %s", key, raw)
+			}
+			raw = items[0]
+		}
+		object = nil
+		if err := json.Unmarshal(raw, &object); err != nil {
+			t.Fatalf("%s: want an object, got %s", key, raw)
+		}
+	}
+
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func assertKeys(t *testing.T, got []string, want ...string) {
+	t.Helper()
+
+	want = append([]string(nil), want...)
+	sort.Strings(want)
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("keys = %v\nwant   %v", got, want)
+	}
+}
+
+func TestPostListResponseContract(t *testing.T) {
+	response := NewPostListResponse([]BlogPost{*contractPost()}, 1, 6, 7)
+
+	assertKeys(t, keysOf(t, response),
+		"api_version", "posts", "current_page", "limit", "total_pages", "total_posts", "has_next", "has_prev")
+	assertKeys(t, keysOf(t, response, "posts"), postKeys...)
+
+	if response.APIVersion != APIVersion || response.TotalPages != 2 || !response.HasNext || response.HasPrev {
+		t.Errorf("pagination = %+v", response)
+	}
+}
+
+func TestPostDetailResponseContract(t *testing.T) {
+	response := NewPostDetailResponse(contractPost())
+
+	assertKeys(t, keysOf(t, response), "api_version", "post")
+	assertKeys(t, keysOf(t, response, "post"),
+		append(postKeys, "content", "content_source", "table_of_contents")...)
+	assertKeys(t, keysOf(t, response, "post", "table_of_contents"), "level", "id", "text")
+}
@@ -0,0 +1,163 @@
+package models
+
+import "time"
+
+// ReviewState is the outcome of a review request
+type ReviewState string
+
+const (
+	// ReviewPending is waiting for the reviewer
+	ReviewPending ReviewState = "pending"
+	// ReviewApproved signs the post off for publishing
+	ReviewApproved ReviewState = "approved"
+	// ReviewChangesRequested sends the post back to its author
+	ReviewChangesRequested ReviewState = "changes_requested"
+)
+
+// Review is a request for an editor to sign off a post before it is published
+type Review struct {
+	ID            uint        `json:"id" gorm:"primaryKey"`
+	PostID        uint        `json:"post_id" gorm:"not null;index"`
+	Post          BlogPost    `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	RequestedByID *uint       `json:"requested_by_id" gorm:"index"`
+	RequestedBy   *User       `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	ReviewerID    *uint       `json:"reviewer_id" gorm:"index"`
+	Reviewer      *User       `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	State         ReviewState `json:"state" gorm:"not null;size:20;default:pending;index"`
+	PostVersion   uint        `json:"post_version" gorm:"not null"` // The post's version when the review was requested, then the version it decided
+	DecidedAt     *time.Time  `json:"decided_at"`
+	CreatedAt     time.Time   `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time   `json:"updated_at" gorm:"autoUpdateTime"`
+
+	Comments []ReviewComment `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+}
+
+// ReviewComment is one entry in a review's discussion. Replies point at the
+// comment they answer through ParentID.
+type ReviewComment struct {
+	ID         uint           `json:"id" gorm:"primaryKey"`
+	ReviewID   uint           `json:"review_id" gorm:"not null;index"`
+	ParentID   *uint          `json:"parent_id" gorm:"index"`
+	Parent     *ReviewComment `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	AuthorID   *uint          `json:"author_id" gorm:"index"`
+	Author     *User          `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	AuthorName string         `json:"author_name" gorm:"not null;size:100"`
+	Body       string         `json:"body" gorm:"type:text;not null"`
+	CreatedAt  time.Time      `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// ReviewResponse represents a review and its discussion in API responses
+type ReviewResponse struct {
+	ID              uint                    `json:"id"`
+	PostID          uint                    `json:"post_id"`
+	State           ReviewState             `json:"state"`
+	RequestedByID   *uint                   `json:"requested_by_id"`
+	RequestedByName string                  `json:"requested_by_name"`
+	ReviewerID      *uint                   `json:"reviewer_id"`
+	ReviewerName    string                  `json:"reviewer_name"`
+	PostVersion     uint                    `json:"post_version"`
+	DecidedAt       *time.Time              `json:"decided_at"`
+	CreatedAt       time.Time               `json:"created_at"`
+	Comments        []ReviewCommentResponse `json:"comments"`
+}
+
+// ToResponse converts Review to ReviewResponse. RequestedBy, Reviewer and
+// Comments must be loaded for the names and the discussion to be filled in.
+func (rv *Review) ToResponse() ReviewResponse {
+	response := ReviewResponse{
+		ID:            rv.ID,
+		PostID:        rv.PostID,
+		State:         rv.State,
+		RequestedByID: rv.RequestedByID,
+		ReviewerID:    rv.ReviewerID,
+		PostVersion:   rv.PostVersion,
+		DecidedAt:     rv.DecidedAt,
+		CreatedAt:     rv.CreatedAt,
+		Comments:      NewReviewThread(rv.Comments),
+	}
+	if rv.RequestedBy != nil {
+		response.RequestedByName = rv.RequestedBy.Name
+	}
+	if rv.Reviewer != nil {
+		response.ReviewerName = rv.Reviewer.Name
+	}
+	return response
+}
+
+// ReviewCommentResponse is a comment with its replies nested under it
+type ReviewCommentResponse struct {
+	ID         uint                    `json:"id"`
+	ParentID   *uint                   `json:"parent_id"`
+	AuthorID   *uint                   `json:"author_id"`
+	AuthorName string                  `json:"author_name"`
+	Body       string                  `json:"body"`
+	CreatedAt  time.Time               `json:"created_at"`
+	Replies    []ReviewCommentResponse `json:"replies"`
+}
+
+// NewReviewThread nests comments under the comments they reply to, oldest
+// first at every level
+func NewReviewThread(comments []ReviewComment) []ReviewCommentResponse {
+	children := make(map[uint][]ReviewComment)
+	var roots []ReviewComment
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			roots = append(roots, comment)
+			continue
+		}
+		children[*comment.ParentID] = append(children[*comment.ParentID], comment)
+	}
+
+	var build func(level []ReviewComment) []ReviewCommentResponse
+	build = func(level []ReviewComment) []ReviewCommentResponse {
+		responses := make([]ReviewCommentResponse, 0, len(level))
+		for _, comment := range level {
+			responses = append(responses, ReviewCommentResponse{
+				ID:         comment.ID,
+				ParentID:   comment.ParentID,
+				AuthorID:   comment.AuthorID,
+				AuthorName: comment.AuthorName,
+				Body:       comment.Body,
+				CreatedAt:  comment.CreatedAt,
+				Replies:    build(children[comment.ID]),
+			})
+		}
+		return responses
+	}
+	return build(roots)
+}
+
+// ReviewListResponse is the contract for endpoints listing reviews
+type ReviewListResponse struct {
+	APIVersion string           `json:"api_version"`
+	Reviews    []ReviewResponse `json:"reviews"`
+}
+
+// ReviewDetailResponse is the contract for endpoints returning one review
+type ReviewDetailResponse struct {
+	APIVersion string         `json:"api_version"`
+	Review     ReviewResponse `json:"review"`
+}
+
+// CreateReviewRequest opens a review. The reviewer can also be assigned later.
+type CreateReviewRequest struct {
+	ReviewerID *uint  `json:"reviewer_id"`
+	Comment    string `json:"comment" validate:"max=10000"`
+}
+
+// AssignReviewerRequest assigns a review to an editor
+type AssignReviewerRequest struct {
+	ReviewerID uint `json:"reviewer_id" validate:"required"`
+}
+
+// ReviewDecisionRequest carries an optional comment with an approval or a
+// request for changes
+type ReviewDecisionRequest struct {
+	Comment string `json:"comment" validate:"max=10000"`
+}
+
+// ReviewCommentRequest adds a comment to a review, optionally as a reply
+type ReviewCommentRequest struct {
+	Body     string `json:"body" validate:"required,max=10000"`
+	ParentID *uint  `json:"parent_id"`
+}
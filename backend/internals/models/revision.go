@@ -0,0 +1,103 @@
+package models
+
+import (
+	"time"
+
+	"blogapp/internals/diff"
+)
+
+// PostRevision is a snapshot of a post as it was saved. One is recorded when
+// the post is created and on every update, numbered by the post's version.
+type PostRevision struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	PostID        uint          `json:"post_id" gorm:"not null;uniqueIndex:idx_post_revisions_post_version"`
+	Post          BlogPost      `json:"-" gorm:"constraint:OnDelete:CASCADE"`
+	Version       uint          `json:"version" gorm:"not null;uniqueIndex:idx_post_revisions_post_version"`
+	Title         string        `json:"title" gorm:"not null;size:255"`
+	Content       string        `json:"content" gorm:"type:text;not null"`
+	ContentFormat ContentFormat `json:"content_format" gorm:"not null;size:20;default:html"`
+	Tags          string        `json:"-" gorm:"size:500"` // Comma separated, like BlogPost.TagNames
+	EditorID      *uint         `json:"editor_id" gorm:"index"`
+	Editor        *User         `json:"-" gorm:"constraint:OnDelete:SET NULL"`
+	EditorName    string        `json:"editor_name" gorm:"size:100"`
+	CreatedAt     time.Time     `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// NewPostRevision snapshots post's current title, content and tags. editor
+// may be nil when the change was not made by a signed-in user.
+func NewPostRevision(post *BlogPost, editor *User) PostRevision {
+	revision := PostRevision{
+		PostID:        post.ID,
+		Version:       post.Version,
+		Title:         post.Title,
+		Content:       post.Content,
+		ContentFormat: post.ContentFormat,
+		Tags:          post.TagNames,
+	}
+	if editor != nil {
+		revision.EditorID = &editor.ID
+		revision.EditorName = editor.Name
+	}
+	return revision
+}
+
+// TagList returns the revision's tags as a slice
+func (pr *PostRevision) TagList() []string {
+	return ParseTags(pr.Tags)
+}
+
+// PostRevisionResponse describes a revision in API responses. Content is
+// only included when a single revision is requested.
+type PostRevisionResponse struct {
+	ID            uint          `json:"id"`
+	Version       uint          `json:"version"`
+	Title         string        `json:"title"`
+	Content       string        `json:"content,omitempty"`
+	ContentFormat ContentFormat `json:"content_format"`
+	Tags          []string      `json:"tags"`
+	EditorID      *uint         `json:"editor_id"`
+	EditorName    string        `json:"editor_name"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+// ToResponse converts PostRevision to PostRevisionResponse
+func (pr *PostRevision) ToResponse(includeContent bool) PostRevisionResponse {
+	response := PostRevisionResponse{
+		ID:            pr.ID,
+		Version:       pr.Version,
+		Title:         pr.Title,
+		ContentFormat: pr.ContentFormat,
+		Tags:          pr.TagList(),
+		EditorID:      pr.EditorID,
+		EditorName:    pr.EditorName,
+		CreatedAt:     pr.CreatedAt,
+	}
+	if includeContent {
+		response.Content = pr.Content
+	}
+	return response
+}
+
+// RevisionListResponse is the contract for GET /api/posts/{slug}/revisions
+type RevisionListResponse struct {
+	APIVersion string                 `json:"api_version"`
+	Revisions  []PostRevisionResponse `json:"revisions"`
+}
+
+// RevisionDetailResponse is the contract for GET /api/posts/{slug}/revisions/{id}
+type RevisionDetailResponse struct {
+	APIVersion string               `json:"api_version"`
+	Revision   PostRevisionResponse `json:"revision"`
+}
+
+// RevisionDiffResponse is the contract for GET /api/posts/{slug}/revisions/{id}/diff.
+// From is nil when the revision is the post's first.
+type RevisionDiffResponse struct {
+	APIVersion   string                `json:"api_version"`
+	From         *PostRevisionResponse `json:"from"`
+	To           PostRevisionResponse  `json:"to"`
+	TitleChanged bool                  `json:"title_changed"`
+	Added        int                   `json:"added"`
+	Removed      int                   `json:"removed"`
+	Lines        []diff.Line           `json:"lines"`
+}
@@ -0,0 +1,254 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/microcosm-cc/bluemonday"
+	"golang.org/x/net/html"
+)
+
+// Allowlist is the markup post content may use; SanitizeContent removes
+// everything else. Relative URLs are always allowed.
+type Allowlist struct {
+	Elements         map[string][]string `json:"elements"`          // Tag name to the attributes it may carry
+	GlobalAttributes []string            `json:"global_attributes"` // Allowed on every tag in Elements
+	URLSchemes       []string            `json:"url_schemes"`       // Allowed in href, src and cite
+}
+
+// DefaultAllowlist returns the markup posts may use unless configured
+// otherwise: text formatting, headings, lists, tables, quotes, code, images
+// and links over http, https and mailto
+func DefaultAllowlist() Allowlist {
+	return Allowlist{
+		Elements: map[string][]string{
+			"a": {"href"}, "abbr": {}, "b": {}, "blockquote": {"cite"}, "br": {},
+			"caption": {}, "cite": {}, "code": {}, "dd": {}, "del": {}, "details": {},
+			"div": {}, "dl": {}, "dt": {}, "em": {}, "figcaption": {}, "figure": {},
+			"h1": {}, "h2": {}, "h3": {}, "h4": {}, "h5": {}, "h6": {}, "hr": {}, "i": {},
+			"img": {"src", "alt", "width", "height"}, "ins": {}, "kbd": {}, "li": {},
+			"mark": {}, "ol": {"start"}, "p": {}, "pre": {}, "q": {"cite"}, "s": {},
+			"small": {}, "span": {}, "strong": {}, "sub": {}, "summary": {}, "sup": {},
+			"table": {}, "tbody": {}, "td": {"align", "colspan", "rowspan"}, "tfoot": {},
+			"th": {"align", "colspan", "rowspan", "scope"}, "thead": {}, "tr": {}, "u": {},
+			"ul": {},
+		},
+		GlobalAttributes: []string{"title", "lang", "dir"},
+		URLSchemes:       []string{"http", "https", "mailto"},
+	}
+}
+
+// Patterns for the attributes the markdown renderer and the heading anchors
+// rely on, which are allowed whatever the allowlist says
+var (
+	anchorIDPattern      = regexp.MustCompile(`^[a-zA-Z0-9:._-]+$`)
+	codeClassPattern     = regexp.MustCompile(`^language-[\w+-]+$`)
+	footnoteClassPattern = regexp.MustCompile(`^footnote(s|-ref|-backref)$`)
+	checkboxPattern      = regexp.MustCompile(`^checkbox$`)
+)
+
+// Elements and URL schemes that run script or load other documents. No
+// allowlist can enable them, and neither event handler nor style attributes.
+var (
+	unsafeElements = map[string]bool{
+		"base": true, "embed": true, "frame": true, "frameset": true, "iframe": true,
+		"link": true, "meta": true, "object": true, "script": true, "style": true,
+	}
+	unsafeSchemes = map[string]bool{"data": true, "javascript": true, "vbscript": true}
+)
+
+func unsafeAttr(name string) bool {
+	name = strings.ToLower(strings.TrimSpace(name))
+	return strings.HasPrefix(name, "on") || name == "style" || name == "srcdoc"
+}
+
+func unsafeScheme(scheme string) bool {
+	return unsafeSchemes[strings.ToLower(strings.TrimSuffix(strings.TrimSpace(scheme), ":"))]
+}
+
+func unsafeElement(element string) bool {
+	return unsafeElements[strings.ToLower(strings.TrimSpace(element))]
+}
+
+// Unsafe lists the entries of the allowlist that would let post content run
+// script or restyle the page, sorted. The sanitiser ignores them.
+func (a Allowlist) Unsafe() []string {
+	var unsafe []string
+	for element, attrs := range a.Elements {
+		if unsafeElement(element) {
+			unsafe = append(unsafe, fmt.Sprintf("element %q", element))
+			continue
+		}
+		for _, attr := range attrs {
+			if unsafeAttr(attr) {
+				unsafe = append(unsafe, fmt.Sprintf("attribute %q on %q", attr, element))
+			}
+		}
+	}
+	for _, attr := range a.GlobalAttributes {
+		if unsafeAttr(attr) {
+			unsafe = append(unsafe, fmt.Sprintf("global attribute %q", attr))
+		}
+	}
+	for _, scheme := range a.URLSchemes {
+		if unsafeScheme(scheme) {
+			unsafe = append(unsafe, fmt.Sprintf("URL scheme %q", scheme))
+		}
+	}
+	sort.Strings(unsafe)
+	return unsafe
+}
+
+// policy builds the sanitiser enforcing the allowlist, less anything Unsafe
+// reports
+func (a Allowlist) policy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowURLSchemes(safe(a.URLSchemes, unsafeScheme)...)
+	p.RequireParseableURLs(true)
+
+	for element, attrs := range a.Elements {
+		if unsafeElement(element) {
+			continue
+		}
+		p.AllowElements(element)
+		if attrs = safe(attrs, unsafeAttr); len(attrs) > 0 {
+			p.AllowAttrs(attrs...).OnElements(element)
+		}
+	}
+	if attrs := safe(a.GlobalAttributes, unsafeAttr); len(attrs) > 0 {
+		p.AllowAttrs(attrs...).Globally()
+	}
+	return p
+}
+
+// contentPolicy adds to the allowlist's policy relative URLs and the
+// attributes the markdown renderer and the heading anchors rely on
+func contentPolicy(a Allowlist) *bluemonday.Policy {
+	p := a.policy()
+	p.AllowRelativeURLs(true)
+	p.AllowAttrs("id").Matching(anchorIDPattern).Globally()
+	p.AllowAttrs("class").Matching(codeClassPattern).OnElements("code")
+	p.AllowAttrs("class").Matching(footnoteClassPattern).OnElements("a", "div")
+	p.AllowAttrs("type").Matching(checkboxPattern).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}
+
+// safe returns the names for which unsafe is false
+func safe(names []string, unsafe func(string) bool) []string {
+	kept := make([]string, 0, len(names))
+	for _, name := range names {
+		if !unsafe(name) {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// postPolicy is the sanitiser applied to post content when it is saved
+var postPolicy = contentPolicy(DefaultAllowlist())
+
+// SetAllowlist replaces the allowlist applied to post content, leaving out
+// anything Unsafe reports. It is meant to be called once at startup, before
+// any post is saved.
+func SetAllowlist(a Allowlist) {
+	postPolicy = contentPolicy(a)
+}
+
+// SanitizeContent returns content with every tag, attribute and URL outside
+// the allowlist removed, including javascript: links, and rel="noopener"
+// on every remaining link
+func SanitizeContent(content string) string {
+	return addNoOpener(postPolicy.Sanitize(content))
+}
+
+// commentAllowlist is the light formatting readers may use in a comment.
+// Unlike posts, comments cannot link relatively.
+func commentAllowlist() Allowlist {
+	return Allowlist{
+		Elements: map[string][]string{
+			"a": {"href"}, "blockquote": {}, "br": {}, "code": {}, "em": {}, "p": {},
+			"pre": {}, "strong": {},
+		},
+		URLSchemes: []string{"http", "https", "mailto"},
+	}
+}
+
+// commentPolicy marks links nofollow, since comments are written by
+// anonymous visitors
+var commentPolicy = func() *bluemonday.Policy {
+	p := commentAllowlist().policy()
+	p.RequireNoFollowOnLinks(true)
+	return p
+}()
+
+// SanitizeComment returns body with every tag, attribute and URL outside the
+// comment allowlist removed, and surrounding whitespace trimmed
+func SanitizeComment(body string) string {
+	return strings.TrimSpace(commentPolicy.Sanitize(body))
+}
+
+// addNoOpener adds noopener to the rel attribute of every link, keeping any
+// other rel values. content must already be sanitised.
+func addNoOpener(content string) string {
+	rewritten, _ := rewriteTokens(content, func(z *html.Tokenizer, tt html.TokenType, raw string) string {
+		if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+			return raw
+		}
+
+		token := z.Token()
+		if token.Data != "a" || !hasAttr(token, "href") {
+			return raw
+		}
+
+		rel := -1
+		for i, attr := range token.Attr {
+			if attr.Key == "rel" {
+				rel = i
+			}
+		}
+		switch {
+		case rel < 0:
+			token.Attr = append(token.Attr, html.Attribute{Key: "rel", Val: "noopener"})
+		case !strings.Contains(" "+token.Attr[rel].Val+" ", " noopener "):
+			token.Attr[rel].Val = strings.TrimSpace(token.Attr[rel].Val + " noopener")
+		}
+		return token.String()
+	})
+	return rewritten
+}
+
+// rewriteTokens re-emits content token by token, writing what edit returns in
+// place of each one; edit returns raw, the token's markup as written, to keep
+// it. Should content not tokenize to the end, it is returned as it was with
+// ok false. The sanitiser only emits well-formed markup, so that is a bug,
+// but one that must not cost the post its content.
+func rewriteTokens(content string, edit func(z *html.Tokenizer, tt html.TokenType, raw string) string) (rewritten string, ok bool) {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(content))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() != io.EOF {
+				return content, false
+			}
+			return b.String(), true
+		}
+
+		// Copied first, as reading the token lowercases the tokenizer's buffer
+		raw := string(z.Raw())
+		b.WriteString(edit(z, tt, raw))
+	}
+}
+
+func hasAttr(token html.Token, key string) bool {
+	for _, attr := range token.Attr {
+		if attr.Key == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+var xssPayloads = []struct {
+	name    string
+	content string
+	absent  []string // Must not survive, compared case-insensitively
+}{
+	{"javascript link", `<a href="javascript:alert(1)">x</a>`, []string{"javascript", "alert"}},
+	{"mixed case scheme", `<a href="JaVaScRiPt:alert(1)">x</a>`, []string{"javascript", "alert"}},
+	{"entity-encoded tab", `<a href="java&#x09;script:alert(1)">x</a>`, []string{"script", "alert"}},
+	{"entity-encoded tab, decimal", `<a href="jav&#9;ascript:alert(1)">x</a>`, []string{"script", "alert"}},
+	{"vbscript link", `<a href="vbscript:msgbox(1)">x</a>`, []string{"vbscript", "msgbox"}},
+	{"data URL image", `<img src="data:text/html;base64,PHNjcmlwdD5hbGVydCgxKTwvc2NyaXB0Pg==">`, []string{"data:", "base64"}},
+	{"img onerror", `<img src="x.png" onerror="alert(1)">`, []string{"onerror", "alert"}},
+	{"svg onload", `<svg onload="alert(1)"><circle r="1"/></svg>`, []string{"onload", "alert"}},
+	{"iframe", `<iframe src="https://example.com/"></iframe>`, []string{"<iframe", "example.com"}},
+	{"iframe srcdoc", `<iframe srcdoc="<script>alert(1)</script>"></iframe>`, []string{"<iframe", "srcdoc", "alert"}},
+	{"script", `<p>Hi</p><script>alert(1)</script>`, []string{"<script", "alert"}},
+	{"style element", `<style>body { display: none }</style><p>Hi</p>`, []string{"<style", "display"}},
+	{"style attribute", `<p style="background: url(javascript:alert(1))">Hi</p>`, []string{"style", "javascript", "alert"}},
+	{"unquoted handler", `<b onmouseover=alert(1)>Hi</b>`, []string{"onmouseover", "alert"}},
+}
+
+func assertSanitized(t *testing.T, sanitize func(string) string) {
+	t.Helper()
+
+	for _, tt := range xssPayloads {
+		got := strings.ToLower(sanitize(tt.content))
+		for _, bad := range tt.absent {
+			if strings.Contains(got, bad) {
+				t.Errorf("%s: %q survived in %q", tt.name, bad, got)
+			}
+		}
+	}
+}
+
+func TestSanitizeContentRemovesScript(t *testing.T) {
+	assertSanitized(t, SanitizeContent)
+}
+
+// An allowlist file may ask for dangerous markup; the sanitiser must still
+// leave it out
+func TestSanitizeContentIgnoresUnsafeAllowlist(t *testing.T) {
+	unsafe := Allowlist{
+		Elements: map[string][]string{
+			"a": {"href", "onclick"}, "b": {"OnMouseOver"}, "img": {"src", "onerror"},
+			"p": {"style"}, "svg": {"onload"}, "iframe": {"src", "srcdoc"},
+			"Script": {}, "style": {},
+		},
+		GlobalAttributes: []string{"title", "onmouseover", "style"},
+		URLSchemes:       []string{"https", "JavaScript", "vbscript:", "data"},
+	}
+
+	want := []string{
+		`URL scheme "JavaScript"`, `URL scheme "data"`, `URL scheme "vbscript:"`,
+		`attribute "OnMouseOver" on "b"`, `attribute "onclick" on "a"`, `attribute "onerror" on "img"`,
+		`attribute "onload" on "svg"`, `attribute "style" on "p"`,
+		`element "Script"`, `element "iframe"`, `element "style"`,
+		`global attribute "onmouseover"`, `global attribute "style"`,
+	}
+	if got := unsafe.Unsafe(); strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unsafe() = %q\nwant %q", got, want)
+	}
+	if got := DefaultAllowlist().Unsafe(); len(got) != 0 {
+		t.Errorf("DefaultAllowlist().Unsafe() = %q", got)
+	}
+
+	SetAllowlist(unsafe)
+	defer SetAllowlist(DefaultAllowlist())
+	assertSanitized(t, SanitizeContent)
+}
+
+func TestSanitizeContentAddsNoOpener(t *testing.T) {
+	tests := []struct{ content, want string }{
+		{`<a href="https://example.com/">x</a>`, `<a href="https://example.com/" rel="noopener">x</a>`},
+		{`<a href="/posts/other">x</a>`, `<a href="/posts/other" rel="noopener">x</a>`},
+		{`<a name="top">x</a>`, `x`},
+		{`<p>No links</p>`, `<p>No links</p>`},
+	}
+
+	for _, tt := range tests {
+		if got := SanitizeContent(tt.content); got != tt.want {
+			t.Errorf("SanitizeContent(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestAddNoOpenerKeepsRel(t *testing.T) {
+	tests := []struct{ content, want string }{
+		{`<a href="/x" rel="nofollow">x</a>`, `<a href="/x" rel="nofollow noopener">x</a>`},
+		{`<a href="/x" rel="noopener">x</a>`, `<a href="/x" rel="noopener">x</a>`},
+	}
+
+	for _, tt := range tests {
+		if got := addNoOpener(tt.content); got != tt.want {
+			t.Errorf("addNoOpener(%q) = %q, want %q", tt.content, got, tt.want)
+		}
+	}
+}
+
+func TestSanitizeComment(t *testing.T) {
+	assertSanitized(t, SanitizeComment)
+	if got := commentAllowlist().Unsafe(); len(got) != 0 {
+		t.Errorf("commentAllowlist().Unsafe() = %q", got)
+	}
+
+	tests := []struct{ body, want string }{
+		{`<a href="https://example.com/">x</a>`, `<a href="https://example.com/" rel="nofollow">x</a>`},
+		{`<a href="/posts/other">x</a>`, `x`},
+		{`<h2 id="title">Title</h2><p>Text</p>`, `Title<p>Text</p>`},
+		{`  <em>Hi</em>  `, `<em>Hi</em>`},
+	}
+	for _, tt := range tests {
+		if got := SanitizeComment(tt.body); got != tt.want {
+			t.Errorf("SanitizeComment(%q) = %q, want %q", tt.body, got, tt.want)
+		}
+	}
+}
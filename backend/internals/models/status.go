@@ -0,0 +1,105 @@
+package models
+
+import "time"
+
+// PostStatus is where a post is in the editorial workflow
+type PostStatus string
+
+const (
+	// StatusDraft is being written and only visible to its editors
+	StatusDraft PostStatus = "draft"
+	// StatusInReview is waiting for an editor to look at it
+	StatusInReview PostStatus = "in_review"
+	// StatusScheduled goes live by itself at PublishedAt
+	StatusScheduled PostStatus = "scheduled"
+	// StatusPublished is public once PublishedAt has passed
+	StatusPublished PostStatus = "published"
+	// StatusArchived was taken down but kept for reference
+	StatusArchived PostStatus = "archived"
+)
+
+// statusTransitions lists where a post may move from each status. Staying in
+// the same status is always allowed.
+var statusTransitions = map[PostStatus][]PostStatus{
+	StatusDraft:     {StatusInReview, StatusScheduled, StatusPublished, StatusArchived},
+	StatusInReview:  {StatusDraft, StatusScheduled, StatusPublished, StatusArchived},
+	StatusScheduled: {StatusDraft, StatusInReview, StatusPublished, StatusArchived},
+	StatusPublished: {StatusDraft, StatusArchived},
+	StatusArchived:  {StatusDraft, StatusPublished},
+}
+
+// Valid reports whether s is one of the known statuses
+func (s PostStatus) Valid() bool {
+	_, ok := statusTransitions[s]
+	return ok
+}
+
+// CanTransitionTo reports whether a post in status s may move to next
+func (s PostStatus) CanTransitionTo(next PostStatus) bool {
+	if s == next {
+		return true
+	}
+	for _, allowed := range statusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Editorial reports whether s is a status authors may set on their own;
+// every other status puts the post in front of readers or takes it away
+func (s PostStatus) Editorial() bool {
+	return s == StatusDraft || s == StatusInReview
+}
+
+// statusFromPublished maps the legacy published flag onto a status
+func statusFromPublished(published bool) PostStatus {
+	if published {
+		return StatusPublished
+	}
+	return StatusDraft
+}
+
+// IsLive reports whether readers can see the post at now: it is published
+// and its publish date is not in the future
+func (bp *BlogPost) IsLive(now time.Time) bool {
+	return bp.Published && (bp.PublishedAt == nil || !bp.PublishedAt.After(now))
+}
+
+// SetStatus moves the post to status, keeping the published flag in step.
+// Publishing stamps PublishedAt unless a date was already chosen, and a
+// published post dated in the future is scheduled instead.
+func (bp *BlogPost) SetStatus(status PostStatus, now time.Time) {
+	if status == StatusPublished {
+		if bp.PublishedAt == nil {
+			bp.PublishedAt = &now
+		} else if bp.PublishedAt.After(now) {
+			status = StatusScheduled
+		}
+	}
+	bp.Status = status
+	bp.Published = status == StatusPublished
+}
+
+// InitialStatus returns the status a new post is created in. Clients that
+// predate the status field only send published.
+func (req *CreateBlogPostRequest) InitialStatus() PostStatus {
+	if req.Status != "" {
+		return req.Status
+	}
+	return statusFromPublished(req.Published)
+}
+
+// RequestedStatus returns the status req asks an existing post in current to
+// move to. When status is missing, or was copied unchanged by a PATCH, a
+// change to the legacy published flag decides instead.
+func (req *CreateBlogPostRequest) RequestedStatus(current PostStatus, currentlyPublished bool) PostStatus {
+	if req.Status != "" && req.Status != current {
+		return req.Status
+	}
+	if req.Published != currentlyPublished {
+		return statusFromPublished(req.Published)
+	}
+	return current
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"blogapp/internals/slug"
+)
+
+// Tag is a label shared by any number of posts. Tags are identified by their
+// slug, so "ADA" and "ada" are the same tag.
+type Tag struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	Name      string    `json:"name" gorm:"not null;size:100"`
+	Slug      string    `json:"slug" gorm:"uniqueIndex;not null;size:100"`
+	CreatedAt time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// PostTag is the post_tags join table between BlogPost and Tag. Its foreign
+// keys come from the constraint on BlogPost.Tags.
+type PostTag struct {
+	PostID uint `gorm:"primaryKey"`
+	TagID  uint `gorm:"primaryKey;index"`
+}
+
+// TagCount is a tag with the number of published posts using it
+type TagCount struct {
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	PostCount int64  `json:"post_count"`
+}
+
+// TagListResponse is the contract for GET /api/tags
+type TagListResponse struct {
+	APIVersion string     `json:"api_version"`
+	Tags       []TagCount `json:"tags"`
+}
+
+// TagPostsResponse is the contract for GET /api/tags/{tag}/posts
+type TagPostsResponse struct {
+	PostListResponse
+	Tag Tag `json:"tag"`
+}
+
+// NewTags builds unsaved tags from names, dropping blanks and duplicates.
+// The first spelling of a name wins.
+func NewTags(names []string) []Tag {
+	tags := []Tag{}
+	seen := make(map[string]bool)
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		tagSlug := TagSlug(name)
+		if seen[tagSlug] {
+			continue
+		}
+		seen[tagSlug] = true
+		tags = append(tags, Tag{Name: name, Slug: tagSlug})
+	}
+	return tags
+}
+
+// TagSlug returns the slug identifying the tag called name
+func TagSlug(name string) string {
+	return slug.Key(name)
+}
+
+// ParseTags splits a comma-separated tag string, as stored before tags had
+// their own table
+func ParseTags(tagsString string) []string {
+	if tagsString == "" {
+		return []string{}
+	}
+
+	tags := strings.Split(tagsString, ",")
+	var cleanTags []string
+
+	for _, tag := range tags {
+		cleanTag := strings.TrimSpace(tag)
+		if cleanTag != "" {
+			cleanTags = append(cleanTags, cleanTag)
+		}
+	}
+
+	return cleanTags
+}
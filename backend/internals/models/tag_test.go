@@ -0,0 +1,76 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTagSlug(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"WCAG", "wcag"},
+		{"Web Development", "web-development"},
+		{"Node.js", "node-js"},
+		{"Café", "cafe"},
+		{"Москва", "moskva"},
+		{"日本語", "日本語"},
+		{"Go 言語", "go-言語"},
+		{"한국어", "한국어"},
+		{"C#", "c-951a4d36"},
+		{"C++", "c-plus-plus"},
+	}
+
+	for _, tt := range tests {
+		if got := TagSlug(tt.name); got != tt.want {
+			t.Errorf("TagSlug(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		// Lookups pass a slug back in, so it must map to itself
+		if got := TagSlug(tt.want); got != tt.want {
+			t.Errorf("TagSlug(%q) = %q, want it unchanged", tt.want, got)
+		}
+	}
+}
+
+// Names that only differ in script or punctuation are different tags and
+// must not share a slug
+func TestTagSlugKeepsNamesApart(t *testing.T) {
+	groups := [][]string{
+		{"日本語", "中文", "한국어", "ニュース", "技術"},
+		{"が", "か"},
+		{"C", "C#", "C++"},
+		{"F", "F#"},
+		{"!!!", "???", "🎉"},
+	}
+
+	for _, names := range groups {
+		seen := make(map[string]string)
+		for _, name := range names {
+			slug := TagSlug(name)
+			if other, ok := seen[slug]; ok {
+				t.Errorf("TagSlug(%q) = TagSlug(%q) = %q", name, other, slug)
+			}
+			seen[slug] = name
+		}
+	}
+}
+
+func TestNewTags(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  []Tag
+	}{
+		{
+			[]string{"日本語", "中文", "한국어"},
+			[]Tag{{Name: "日本語", Slug: "日本語"}, {Name: "中文", Slug: "中文"}, {Name: "한국어", Slug: "한국어"}},
+		},
+		{
+			[]string{"C", "C#", "c#", " ", "ADA", "ada"},
+			[]Tag{{Name: "C", Slug: "c"}, {Name: "C#", Slug: "c-951a4d36"}, {Name: "ADA", Slug: "ada"}},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := NewTags(tt.names); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("NewTags(%q) = %+v, want %+v", tt.names, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+
+	"blogapp/internals/slug"
+)
+
+// WordsPerMinute is the reading speed ReadingTimeMinutes is based on
+const WordsPerMinute = 200
+
+// TOCEntry is a heading in a post's table of contents
+type TOCEntry struct {
+	Level int    `json:"level"` // 2 or 3
+	ID    string `json:"id"`    // Anchor id of the heading in the post content
+	Text  string `json:"text"`
+}
+
+// TableOfContents lists a post's h2 and h3 headings in document order. It is
+// stored as JSON.
+type TableOfContents []TOCEntry
+
+// Value stores the table of contents as a JSON array, never NULL, so rows
+// saved before it existed can be told apart
+func (t TableOfContents) Value() (driver.Value, error) {
+	if t == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan reads a table of contents stored by Value
+func (t *TableOfContents) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*t = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into TableOfContents", value)
+	}
+	return json.Unmarshal(data, t)
+}
+
+// tocHeadings are the heading levels listed in the table of contents
+var tocHeadings = map[string]int{"h2": 2, "h3": 3}
+
+// addHeadingAnchors gives every h2 and h3 in content an id to link to and
+// lists them. Ids already in the markup are kept; the rest are made from the
+// heading text, with -2, -3 and so on added to repeats. content must already
+// be sanitised.
+func addHeadingAnchors(content string) (string, TableOfContents) {
+	var (
+		toc     TableOfContents
+		used    = make(map[string]bool)
+		heading *html.Token     // Open h2 or h3, written out once its text is known
+		inner   strings.Builder // Markup inside the open heading
+		text    strings.Builder // Text inside the open heading
+	)
+
+	rewritten, ok := rewriteTokens(content, func(z *html.Tokenizer, tt html.TokenType, raw string) string {
+		if heading == nil {
+			if tt == html.StartTagToken {
+				if token := z.Token(); tocHeadings[token.Data] != 0 {
+					heading = &token
+					return ""
+				}
+			}
+			return raw
+		}
+
+		if tt == html.EndTagToken {
+			if name, _ := z.TagName(); string(name) == heading.Data {
+				entry := TOCEntry{
+					Level: tocHeadings[heading.Data],
+					Text:  strings.Join(strings.Fields(text.String()), " "),
+				}
+				entry.ID = headingID(heading, entry.Text, used)
+				toc = append(toc, entry)
+
+				markup := heading.String() + inner.String() + raw
+				heading = nil
+				inner.Reset()
+				text.Reset()
+				return markup
+			}
+		}
+		if tt == html.TextToken {
+			text.Write(z.Text())
+		}
+		inner.WriteString(raw)
+		return ""
+	})
+	// A heading left open at the end would be held back with its content
+	if !ok || heading != nil {
+		return content, nil
+	}
+	return rewritten, toc
+}
+
+// headingID returns the id of heading, setting one made from its text if it
+// has none, and records it in used
+func headingID(heading *html.Token, text string, used map[string]bool) string {
+	for _, attr := range heading.Attr {
+		if attr.Key == "id" && attr.Val != "" {
+			used[attr.Val] = true
+			return attr.Val
+		}
+	}
+
+	base := slug.MakeOr(text, "section")
+	id := base
+	for n := 2; used[id]; n++ {
+		id = base + "-" + strconv.Itoa(n)
+	}
+	used[id] = true
+
+	heading.Attr = append(heading.Attr, html.Attribute{Key: "id", Val: id})
+	return id
+}
+
+// countWords counts the words in plain text. Chinese and Japanese are written
+// without spaces, so each of their characters counts as a word.
+func countWords(text string) int {
+	words := 0
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana):
+			words++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+				inWord = true
+			}
+		case unicode.IsMark(r) || r == '\'' || r == '’' || r == '-':
+			// Part of the word around it, as in "don't" or "well-known"
+		default:
+			inWord = false
+		}
+	}
+	return words
+}
+
+// readingTime returns the minutes it takes to read words at WordsPerMinute,
+// rounded up, and at least one for any text at all
+func readingTime(words int) int {
+	return int(math.Ceil(float64(words) / WordsPerMinute))
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAddHeadingAnchors(t *testing.T) {
+	content := `<h1>Title</h1><h2>Why <em>bother</em>?</h2><p>Text</p><h3 id="own">Kept</h3>` +
+		`<h2>Why bother?</h2><h3>!!!</h3><h4>Too deep</h4>`
+
+	got, toc := addHeadingAnchors(content)
+
+	want := `<h1>Title</h1><h2 id="why-bother">Why <em>bother</em>?</h2><p>Text</p><h3 id="own">Kept</h3>` +
+		`<h2 id="why-bother-2">Why bother?</h2><h3 id="section">!!!</h3><h4>Too deep</h4>`
+	if got != want {
+		t.Errorf("content = %q\nwant      %q", got, want)
+	}
+
+	wantTOC := TableOfContents{
+		{Level: 2, ID: "why-bother", Text: "Why bother?"},
+		{Level: 3, ID: "own", Text: "Kept"},
+		{Level: 2, ID: "why-bother-2", Text: "Why bother?"},
+		{Level: 3, ID: "section", Text: "!!!"},
+	}
+	if !reflect.DeepEqual(toc, wantTOC) {
+		t.Errorf("toc = %+v\nwant  %+v", toc, wantTOC)
+	}
+}
+
+func TestAddHeadingAnchorsKeepsUnclosedHeading(t *testing.T) {
+	content := `<p>Intro</p><h2>Never closed`
+	if got, toc := addHeadingAnchors(content); got != content || toc != nil {
+		t.Errorf("addHeadingAnchors(%q) = %q, %v", content, got, toc)
+	}
+}
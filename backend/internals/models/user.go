@@ -0,0 +1,137 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Role determines what a user is allowed to do
+type Role string
+
+const (
+	// RoleAdmin can do everything, including managing users
+	RoleAdmin Role = "admin"
+	// RoleEditor can edit, publish and feature any post
+	RoleEditor Role = "editor"
+	// RoleAuthor can write posts and edit their own
+	RoleAuthor Role = "author"
+	// RoleReader can sign in but not write
+	RoleReader Role = "reader"
+)
+
+// Valid reports whether r is one of the known roles
+func (r Role) Valid() bool {
+	switch r {
+	case RoleAdmin, RoleEditor, RoleAuthor, RoleReader:
+		return true
+	}
+	return false
+}
+
+// User represents an account that can sign in and write posts
+type User struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Email        string    `json:"email" gorm:"uniqueIndex;not null;size:255" validate:"required,email"`
+	Name         string    `json:"name" gorm:"not null;size:100" validate:"required"`
+	Role         Role      `json:"role" gorm:"not null;size:20;default:reader"`
+	PasswordHash string    `json:"-" gorm:"not null;size:255"`
+	CreatedAt    time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt    time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// BeforeSave hook to normalise the email address and default the role
+func (u *User) BeforeSave(tx *gorm.DB) error {
+	u.Email = NormalizeEmail(u.Email)
+	if u.Role == "" {
+		u.Role = RoleReader
+	}
+	return nil
+}
+
+// SetPassword stores a bcrypt hash of the given password
+func (u *User) SetPassword(password string) error {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	u.PasswordHash = string(hash)
+	return nil
+}
+
+// CheckPassword reports whether password matches the stored hash
+func (u *User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
+
+// UserResponse represents a user in API responses
+type UserResponse struct {
+	ID        uint      `json:"id"`
+	Email     string    `json:"email"`
+	Name      string    `json:"name"`
+	Role      Role      `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ToResponse converts User to UserResponse
+func (u *User) ToResponse() UserResponse {
+	return UserResponse{
+		ID:        u.ID,
+		Email:     u.Email,
+		Name:      u.Name,
+		Role:      u.Role,
+		CreatedAt: u.CreatedAt,
+	}
+}
+
+// UserListResponse is the contract for GET /api/admin/users
+type UserListResponse struct {
+	APIVersion string         `json:"api_version"`
+	Users      []UserResponse `json:"users"`
+}
+
+// UserDetailResponse is the contract for endpoints returning one user
+type UserDetailResponse struct {
+	APIVersion string       `json:"api_version"`
+	User       UserResponse `json:"user"`
+}
+
+// CreateUserRequest represents the request structure for creating a user
+type CreateUserRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Password string `json:"password" validate:"required,min=8"`
+	Role     Role   `json:"role" validate:"required"`
+}
+
+// UpdateUserRequest represents the request structure for updating a user.
+// Omitted fields are left unchanged.
+type UpdateUserRequest struct {
+	Name     *string `json:"name" validate:"omitnil,min=1,max=100"`
+	Password *string `json:"password" validate:"omitempty,min=8"`
+	Role     *Role   `json:"role"`
+}
+
+// RefreshToken is a long-lived, revocable credential used to obtain new access
+// tokens. Only a SHA-256 hash of the token is stored.
+type RefreshToken struct {
+	ID        uint       `gorm:"primaryKey"`
+	UserID    uint       `gorm:"not null;index"`
+	User      User       `gorm:"constraint:OnDelete:CASCADE"`
+	TokenHash string     `gorm:"uniqueIndex;not null;size:64"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	RevokedAt *time.Time `gorm:"index"`
+	CreatedAt time.Time  `gorm:"autoCreateTime"`
+}
+
+// Active reports whether the token can still be exchanged
+func (rt *RefreshToken) Active(now time.Time) bool {
+	return rt.RevokedAt == nil && now.Before(rt.ExpiresAt)
+}
+
+// NormalizeEmail lowercases and trims an email address for lookups
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
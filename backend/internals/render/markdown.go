@@ -0,0 +1,27 @@
+// Package render turns post sources into HTML
+package render
+
+import (
+	"bytes"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/renderer/html"
+)
+
+// markdown renders GitHub Flavored Markdown (tables, strikethrough, task
+// lists and autolinks) plus footnotes. Raw HTML in the source is passed
+// through, since authors may mix the two; callers sanitise the output.
+var markdown = goldmark.New(
+	goldmark.WithExtensions(extension.GFM, extension.Footnote),
+	goldmark.WithRendererOptions(html.WithUnsafe()),
+)
+
+// Markdown returns source rendered as HTML. The result is not sanitised.
+func Markdown(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := markdown.Convert([]byte(source), &buf); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
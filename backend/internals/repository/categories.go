@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"blogapp/internals/models"
+)
+
+// ErrCategoryNotFound is returned when no category has the requested slug or id
+var ErrCategoryNotFound = errors.New("category not found")
+
+// categoryTreeSQL selects the ids of the categories matching the condition
+// and all of their descendants. UNION rather than UNION ALL stops the
+// recursion should a cycle ever slip into parent_id.
+const categoryTreeSQL = `WITH RECURSIVE category_tree(id) AS (
+	SELECT id FROM categories WHERE %s
+	UNION
+	SELECT categories.id FROM categories JOIN category_tree ON categories.parent_id = category_tree.id
+) SELECT id FROM category_tree`
+
+// CategoryTreeIDs returns the id of the category and of all its descendants
+func CategoryTreeIDs(tx *gorm.DB, categoryID uint) ([]uint, error) {
+	var ids []uint
+	err := tx.Raw(fmt.Sprintf(categoryTreeSQL, "id = ?"), categoryID).Scan(&ids).Error
+	return ids, err
+}
+
+// MigrateCategories turns the names still held in the old free-text
+// blog_posts.category column into categories and points the posts at them.
+// Migrated values are cleared, so it is safe to run on every start.
+func MigrateCategories(db *gorm.DB) error {
+	if !db.Migrator().HasColumn(&models.BlogPost{}, "category") {
+		return nil
+	}
+
+	var names []string
+	if err := db.Model(&models.BlogPost{}).
+		Where("category IS NOT NULL AND category <> ''").
+		Distinct().
+		Pluck("category", &names).Error; err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			category := models.Category{Name: name, Slug: models.CategorySlug(name)}
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "slug"}},
+				DoNothing: true,
+			}).Create(&category).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("slug = ?", category.Slug).First(&category).Error; err != nil {
+				return err
+			}
+
+			return tx.Model(&models.BlogPost{}).
+				Where("category = ?", name).
+				UpdateColumns(map[string]interface{}{
+					"category_id": gorm.Expr("COALESCE(category_id, ?)", category.ID),
+					"category":    nil,
+				}).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListCategories implements PostRepository
+func (r *GormPostRepository) ListCategories(ctx context.Context) ([]models.CategoryResponse, error) {
+	var categories []models.Category
+	if err := r.db.WithContext(ctx).Find(&categories).Error; err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		CategoryID uint
+		PostCount  int64
+	}
+	if err := Live(r.db.WithContext(ctx).Model(&models.BlogPost{})).
+		Select("category_id, COUNT(*) AS post_count").
+		Where("category_id IS NOT NULL").
+		Group("category_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	direct := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		direct[row.CategoryID] = row.PostCount
+	}
+
+	return categoryCounts(categories, direct), nil
+}
+
+// ListCategories implements PostRepository
+func (r *MemoryPostRepository) ListCategories(ctx context.Context) ([]models.CategoryResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	var categories []models.Category
+	seen := make(map[uint]bool)
+	direct := make(map[uint]int64)
+	for _, post := range r.posts {
+		if post.Category == nil {
+			continue
+		}
+		if !seen[post.Category.ID] {
+			seen[post.Category.ID] = true
+			categories = append(categories, *post.Category)
+		}
+		if post.IsLive(now) {
+			direct[post.Category.ID]++
+		}
+	}
+
+	return categoryCounts(categories, direct), nil
+}
+
+// categoryTree returns the IDs of the category with the given slug and of all
+// its descendants, as categoryTreeSQL does for the database. The caller must
+// hold r.mu.
+func (r *MemoryPostRepository) categoryTree(categorySlug string) map[uint]bool {
+	parents := make(map[uint]*uint)
+	tree := make(map[uint]bool)
+	for _, post := range r.posts {
+		if post.Category == nil {
+			continue
+		}
+		parents[post.Category.ID] = post.Category.ParentID
+		if post.Category.Slug == categorySlug {
+			tree[post.Category.ID] = true
+		}
+	}
+
+	for id := range parents {
+		// The visited set guards against a cycle in parent_id
+		visited := make(map[uint]bool)
+		for current := &id; current != nil && !visited[*current]; current = parents[*current] {
+			visited[*current] = true
+			if tree[*current] {
+				tree[id] = true
+				break
+			}
+		}
+	}
+	return tree
+}
+
+// categoryCounts adds each category's direct post count to those of all its
+// ancestors and returns the categories sorted by name
+func categoryCounts(categories []models.Category, direct map[uint]int64) []models.CategoryResponse {
+	parents := make(map[uint]*uint, len(categories))
+	for _, category := range categories {
+		parents[category.ID] = category.ParentID
+	}
+
+	totals := make(map[uint]int64, len(categories))
+	for id, count := range direct {
+		// The visited set guards against a cycle in parent_id
+		visited := make(map[uint]bool)
+		for current := &id; current != nil && !visited[*current]; current = parents[*current] {
+			visited[*current] = true
+			totals[*current] += count
+		}
+	}
+
+	sort.Slice(categories, func(i, j int) bool {
+		return categories[i].Name < categories[j].Name
+	})
+
+	result := make([]models.CategoryResponse, 0, len(categories))
+	for i := range categories {
+		result = append(result, categories[i].ToResponse(totals[categories[i].ID]))
+	}
+	return result
+}
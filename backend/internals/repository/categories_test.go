@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"blogapp/internals/models"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "blog.db")), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.SetupJoinTable(&models.BlogPost{}, "Tags", &models.PostTag{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.BlogPost{}, &models.Tag{}, &models.PostTag{}, &models.Category{}, &models.Comment{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// The memory repository must filter categories the way categoryTreeSQL does,
+// taking in every subcategory
+func TestCategoryFilterIncludesSubcategories(t *testing.T) {
+	db := openTestDB(t)
+
+	var parent *uint
+	var categories []*models.Category
+	for _, name := range []string{"Web", "日本語", "中文"} {
+		category := &models.Category{Name: name, Slug: models.CategorySlug(name), ParentID: parent}
+		if err := db.Create(category).Error; err != nil {
+			t.Fatal(err)
+		}
+		categories = append(categories, category)
+		parent = &category.ID
+	}
+	other := &models.Category{Name: "C#", Slug: models.CategorySlug("C#")}
+	if err := db.Create(other).Error; err != nil {
+		t.Fatal(err)
+	}
+	categories = append(categories, other)
+
+	publishedAt := time.Now().Add(-time.Hour)
+	for i, category := range categories {
+		post := models.BlogPost{
+			Title:         "Post in " + category.Name,
+			Slug:          "post-" + string(rune('a'+i)),
+			Content:       "<p>" + strings.Repeat("Filed under a category. ", 5) + "</p>",
+			ContentFormat: models.FormatHTML,
+			Status:        models.StatusPublished,
+			Published:     true,
+			PublishedAt:   &publishedAt,
+		}
+		post.SetCategory(category)
+		if err := db.Create(&post).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var posts []models.BlogPost
+	if err := db.Preload("Category").Find(&posts).Error; err != nil {
+		t.Fatal(err)
+	}
+	repositories := map[string]PostRepository{
+		"gorm":   NewGormPostRepository(db),
+		"memory": NewMemoryPostRepository(posts),
+	}
+
+	tests := []struct {
+		category string
+		want     []string
+	}{
+		{"Web", []string{"post-a", "post-b", "post-c"}},
+		{"日本語", []string{"post-b", "post-c"}},
+		{"中文", []string{"post-c"}},
+		{"C", nil},
+		{"C#", []string{"post-d"}},
+	}
+
+	for name, repo := range repositories {
+		for _, tt := range tests {
+			found, _, err := repo.ListPublished(context.Background(), PostQuery{Page: 1, Limit: 10, Category: tt.category})
+			if err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+			var got []string
+			for _, post := range found {
+				got = append(got, post.Slug)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("%s: category %q = %v, want %v", name, tt.category, got, tt.want)
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"blogapp/internals/models"
+)
+
+// AttachCommentCounts fills in CommentCount on every post with the number of
+// its approved comments, using one query for the whole page
+func AttachCommentCounts(db *gorm.DB, posts []models.BlogPost) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+
+	var rows []struct {
+		PostID uint
+		Count  int64
+	}
+	if err := db.Model(&models.Comment{}).
+		Select("post_id, COUNT(*) AS count").
+		Where("post_id IN ? AND status = ?", ids, models.CommentApproved).
+		Group("post_id").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	counts := make(map[uint]int64, len(rows))
+	for _, row := range rows {
+		counts[row.PostID] = row.Count
+	}
+	for i := range posts {
+		posts[i].CommentCount = counts[posts[i].ID]
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"blogapp/internals/models"
+)
+
+// MigrateContent re-renders every post and writes back what is derived from
+// its content: content_html, the excerpt, the table of contents, the word
+// count and the reading time. That fills them in for posts stored before they
+// existed and replaces those made by older code, such as excerpts cut in the
+// middle of a character. Posts that are up to date are not written, so it is
+// safe to run on every start.
+func MigrateContent(db *gorm.DB) error {
+	var posts []models.BlogPost
+	return db.Unscoped().
+		Select("id", "content", "content_format", "content_html", "excerpt", "table_of_contents", "word_count").
+		FindInBatches(&posts, 100, func(tx *gorm.DB, batch int) error {
+			for _, post := range posts {
+				stored := post
+				if err := post.Render(); err != nil {
+					return err
+				}
+				if post.ContentHTML == stored.ContentHTML && post.Excerpt == stored.Excerpt &&
+					post.WordCount == stored.WordCount && stored.TableOfContents != nil {
+					continue
+				}
+
+				// Only the derived columns are written; the stored content is left as it was
+				if err := db.Unscoped().Model(&models.BlogPost{}).Where("id = ?", post.ID).UpdateColumns(map[string]interface{}{
+					"content_html":         post.ContentHTML,
+					"excerpt":              post.Excerpt,
+					"table_of_contents":    post.TableOfContents,
+					"word_count":           post.WordCount,
+					"reading_time_minutes": post.ReadingTimeMinutes,
+				}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"blogapp/internals/models"
+)
+
+// facetCounter counts values case-insensitively, reporting each under the
+// spelling it was first seen with
+type facetCounter struct {
+	counts map[string]*models.FacetCount
+}
+
+func newFacetCounter() *facetCounter {
+	return &facetCounter{counts: make(map[string]*models.FacetCount)}
+}
+
+func (c *facetCounter) add(value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	key := strings.ToLower(value)
+	if fc, ok := c.counts[key]; ok {
+		fc.Count++
+		return
+	}
+	c.counts[key] = &models.FacetCount{Value: value, Count: 1}
+}
+
+// sorted returns the counts, most common first
+func (c *facetCounter) sorted() []models.FacetCount {
+	result := make([]models.FacetCount, 0, len(c.counts))
+	for _, fc := range c.counts {
+		result = append(result, *fc)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Value < result[j].Value
+	})
+	return result
+}
+
+// buildFacets counts posts by category, tag, author and publish year. Years
+// are listed newest first rather than by count.
+func buildFacets(posts []models.BlogPost) models.SearchFacets {
+	categories, tags, authors, years := newFacetCounter(), newFacetCounter(), newFacetCounter(), newFacetCounter()
+
+	for _, post := range posts {
+		if post.Category != nil {
+			categories.add(post.Category.Name)
+		}
+		authors.add(post.AuthorName)
+		for _, tag := range post.TagList() {
+			tags.add(tag)
+		}
+		if post.PublishedAt != nil {
+			years.add(strconv.Itoa(post.PublishedAt.UTC().Year()))
+		}
+	}
+
+	yearCounts := years.sorted()
+	sort.Slice(yearCounts, func(i, j int) bool {
+		return yearCounts[i].Value > yearCounts[j].Value
+	})
+
+	return models.SearchFacets{
+		Categories: categories.sorted(),
+		Tags:       tags.sorted(),
+		Authors:    authors.sorted(),
+		Years:      yearCounts,
+	}
+}
@@ -0,0 +1,206 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"blogapp/internals/models"
+)
+
+// GormPostRepository reads posts from the blog_posts table. It runs on
+// Postgres and SQLite; see search.go for how each one does full-text search.
+type GormPostRepository struct {
+	db       *gorm.DB
+	fullText fullTextEngine
+}
+
+func NewGormPostRepository(db *gorm.DB) *GormPostRepository {
+	return &GormPostRepository{db: db, fullText: detectFullText(db)}
+}
+
+// SearchBackend names the engine used for ?search= queries
+func (r *GormPostRepository) SearchBackend() string {
+	return r.fullText.String()
+}
+
+// ListPublished implements PostRepository
+func (r *GormPostRepository) ListPublished(ctx context.Context, q PostQuery) ([]models.BlogPost, int64, error) {
+	query, ranked, ok := r.filter(ctx, q)
+	if !ok {
+		return []models.BlogPost{}, 0, nil
+	}
+
+	var totalCount int64
+	if err := query.Count(&totalCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Rank full-text matches by relevance, newest first on ties
+	if ranked {
+		query = r.fullText.rank(query, q.Search)
+	}
+
+	var posts []models.BlogPost
+	if err := query.
+		Preload("Tags").
+		Preload("Category").
+		Order("blog_posts.published_at DESC, blog_posts.created_at DESC").
+		Offset(q.Offset()).
+		Limit(q.Limit).
+		Find(&posts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if ranked && r.fullText == fullTextFTS5 {
+		for i := range posts {
+			posts[i].SearchHeadline = cleanSnippet(posts[i].SearchHeadline)
+		}
+	}
+
+	if err := AttachCommentCounts(r.db.WithContext(ctx), posts); err != nil {
+		return nil, 0, err
+	}
+
+	return posts, totalCount, nil
+}
+
+// Facets implements PostRepository. Each facet is counted by the database
+// with a GROUP BY over the ids of the matching posts.
+func (r *GormPostRepository) Facets(ctx context.Context, q PostQuery) (models.SearchFacets, error) {
+	query, _, ok := r.filter(ctx, q)
+	if !ok {
+		return buildFacets(nil), nil
+	}
+	ids := query.Select("blog_posts.id")
+
+	db := r.db.WithContext(ctx)
+	year := "strftime('%Y', blog_posts.published_at)"
+	if db.Dialector.Name() == "postgres" {
+		year = "TO_CHAR(blog_posts.published_at AT TIME ZONE 'UTC', 'YYYY')"
+	}
+
+	var facets models.SearchFacets
+	for _, facet := range []struct {
+		dest  *[]models.FacetCount
+		from  *gorm.DB
+		value string
+		order string
+	}{
+		{&facets.Categories, db.Table("blog_posts").Joins("JOIN categories ON categories.id = blog_posts.category_id"), "categories.name", "count DESC, value"},
+		{&facets.Tags, db.Table("blog_posts").Joins("JOIN post_tags ON post_tags.post_id = blog_posts.id").Joins("JOIN tags ON tags.id = post_tags.tag_id"), "tags.name", "count DESC, value"},
+		{&facets.Authors, db.Table("blog_posts"), "blog_posts.author_name", "count DESC, value"},
+		{&facets.Years, db.Table("blog_posts").Where("blog_posts.published_at IS NOT NULL"), year, "value DESC"},
+	} {
+		// Values differing only in case are counted together, as buildFacets does
+		*facet.dest = []models.FacetCount{}
+		if err := facet.from.
+			Select("MIN("+facet.value+") AS value, COUNT(*) AS count").
+			Where("blog_posts.id IN (?)", ids).
+			Where(facet.value + " <> ''").
+			Group("LOWER(" + facet.value + ")").
+			Order(facet.order).
+			Scan(facet.dest).Error; err != nil {
+			return models.SearchFacets{}, err
+		}
+	}
+
+	return facets, nil
+}
+
+// filter builds the query for published posts matching q. ranked reports
+// whether a full-text match was applied; ok is false when nothing can match.
+func (r *GormPostRepository) filter(ctx context.Context, q PostQuery) (query *gorm.DB, ranked bool, ok bool) {
+	query = Live(r.db.WithContext(ctx).Model(&models.BlogPost{}))
+
+	// Apply search filter
+	if q.Search != "" {
+		if q.SearchMode != SearchSubstring && r.fullText != fullTextNone {
+			if query, ok = r.fullText.match(query, q.Search); !ok {
+				return query, false, false
+			}
+			ranked = true
+		} else {
+			searchPattern := "%" + strings.ToLower(q.Search) + "%"
+			query = query.Where(
+				"LOWER(blog_posts.title) LIKE ? OR LOWER(blog_posts.content) LIKE ? OR LOWER(blog_posts.excerpt) LIKE ? OR LOWER(blog_posts.tags) LIKE ?",
+				searchPattern, searchPattern, searchPattern, searchPattern,
+			)
+		}
+	}
+
+	// Apply category filter; q.Category may be a category's name or its slug,
+	// and posts in its subcategories match too
+	if q.Category != "" {
+		query = query.Where(
+			"blog_posts.category_id IN ("+fmt.Sprintf(categoryTreeSQL, "slug = ?")+")",
+			models.CategorySlug(q.Category),
+		)
+	}
+
+	// Apply tag filter; q.Tag may be a tag's name or its slug
+	if q.Tag != "" {
+		query = query.Where(
+			"blog_posts.id IN (SELECT post_tags.post_id FROM post_tags JOIN tags ON tags.id = post_tags.tag_id WHERE tags.slug = ?)",
+			models.TagSlug(q.Tag),
+		)
+	}
+
+	// Apply author filter
+	if q.Author != "" {
+		query = query.Where("LOWER(blog_posts.author_name) = ?", strings.ToLower(q.Author))
+	}
+
+	// Apply publish year filter
+	if q.Year != 0 {
+		start := time.Date(q.Year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		query = query.Where("blog_posts.published_at >= ? AND blog_posts.published_at < ?", start, start.AddDate(1, 0, 0))
+	}
+
+	// Apply featured filter
+	if q.Featured {
+		query = query.Where("blog_posts.featured = ?", true)
+	}
+
+	return query, ranked, true
+}
+
+// GetPublishedBySlug implements PostRepository
+func (r *GormPostRepository) GetPublishedBySlug(ctx context.Context, slug string) (*models.BlogPost, error) {
+	var post models.BlogPost
+	if err := Live(r.db.WithContext(ctx)).Preload("Tags").Preload("Category").Where("blog_posts.slug = ?", slug).First(&post).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPostNotFound
+		}
+		return nil, err
+	}
+
+	if err := r.db.WithContext(ctx).Model(&models.Comment{}).
+		Where("post_id = ? AND status = ?", post.ID, models.CommentApproved).
+		Count(&post.CommentCount).Error; err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+// ResolveSlug implements PostRepository
+func (r *GormPostRepository) ResolveSlug(ctx context.Context, oldSlug string) (string, error) {
+	var current []string
+	if err := r.db.WithContext(ctx).
+		Table("slug_history").
+		Joins("JOIN blog_posts ON blog_posts.id = slug_history.post_id").
+		Where("slug_history.slug = ? AND blog_posts.deleted_at IS NULL", oldSlug).
+		Where(liveCondition, true, time.Now()).
+		Limit(1).
+		Pluck("blog_posts.slug", &current).Error; err != nil {
+		return "", err
+	}
+	if len(current) == 0 {
+		return "", ErrPostNotFound
+	}
+	return current[0], nil
+}
@@ -0,0 +1,146 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"blogapp/internals/models"
+)
+
+// MemoryPostRepository serves posts from an in-memory slice. It backs the
+// mock data source and is never used as a silent fallback for a failed database.
+type MemoryPostRepository struct {
+	mu    sync.RWMutex
+	posts []models.BlogPost
+}
+
+func NewMemoryPostRepository(posts []models.BlogPost) *MemoryPostRepository {
+	sorted := make([]models.BlogPost, len(posts))
+	copy(sorted, posts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return publishedAfter(sorted[i], sorted[j])
+	})
+	return &MemoryPostRepository{posts: sorted}
+}
+
+// ListPublished implements PostRepository
+func (r *MemoryPostRepository) ListPublished(ctx context.Context, q PostQuery) ([]models.BlogPost, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	matches := r.matching(q)
+
+	total := int64(len(matches))
+	start := q.Offset()
+	if start >= len(matches) {
+		return []models.BlogPost{}, total, nil
+	}
+	end := start + q.Limit
+	if end > len(matches) {
+		end = len(matches)
+	}
+
+	return matches[start:end], total, nil
+}
+
+// Facets implements PostRepository
+func (r *MemoryPostRepository) Facets(ctx context.Context, q PostQuery) (models.SearchFacets, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return buildFacets(r.matching(q)), nil
+}
+
+// matching returns every published post that passes q's filters, newest first
+func (r *MemoryPostRepository) matching(q PostQuery) []models.BlogPost {
+	var matches []models.BlogPost
+	var categories map[uint]bool
+	if q.Category != "" {
+		categories = r.categoryTree(models.CategorySlug(q.Category))
+	}
+	now := time.Now()
+	for _, post := range r.posts {
+		if !post.IsLive(now) {
+			continue
+		}
+		if q.Search != "" && !matchesSearch(post, q.Search) {
+			continue
+		}
+		if q.Category != "" && (post.Category == nil || !categories[post.Category.ID]) {
+			continue
+		}
+		if q.Tag != "" && !hasTag(post, q.Tag) {
+			continue
+		}
+		if q.Author != "" && !strings.EqualFold(post.AuthorName, q.Author) {
+			continue
+		}
+		if q.Year != 0 && (post.PublishedAt == nil || post.PublishedAt.UTC().Year() != q.Year) {
+			continue
+		}
+		if q.Featured && !post.Featured {
+			continue
+		}
+		matches = append(matches, post)
+	}
+	return matches
+}
+
+// GetPublishedBySlug implements PostRepository
+func (r *MemoryPostRepository) GetPublishedBySlug(ctx context.Context, slug string) (*models.BlogPost, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, post := range r.posts {
+		if post.Slug == slug && post.IsLive(time.Now()) {
+			found := post
+			return &found, nil
+		}
+	}
+	return nil, ErrPostNotFound
+}
+
+// ResolveSlug implements PostRepository. Fixture posts are never renamed.
+func (r *MemoryPostRepository) ResolveSlug(ctx context.Context, oldSlug string) (string, error) {
+	return "", ErrPostNotFound
+}
+
+// matchesSearch mirrors the columns searched by GormPostRepository
+func matchesSearch(post models.BlogPost, search string) bool {
+	search = strings.ToLower(search)
+	for _, field := range []string{post.Title, post.Content, post.Excerpt, post.TagNames} {
+		if strings.Contains(strings.ToLower(field), search) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasTag reports whether the post has the tag with the given name or slug
+func hasTag(post models.BlogPost, tag string) bool {
+	tagSlug := models.TagSlug(tag)
+	for _, t := range post.Tags {
+		if t.Slug == tagSlug {
+			return true
+		}
+	}
+	return false
+}
+
+func publishedAfter(a, b models.BlogPost) bool {
+	switch {
+	case a.PublishedAt == nil && b.PublishedAt == nil:
+		return a.CreatedAt.After(b.CreatedAt)
+	case a.PublishedAt == nil:
+		return false
+	case b.PublishedAt == nil:
+		return true
+	}
+	if a.PublishedAt.Equal(*b.PublishedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.PublishedAt.After(*b.PublishedAt)
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"blogapp/internals/models"
+)
+
+// ErrPostNotFound is returned when no published post matches a lookup
+var ErrPostNotFound = errors.New("post not found")
+
+// SearchMode selects how PostQuery.Search is matched
+type SearchMode string
+
+const (
+	// SearchFullText uses the database's full-text index and ranks results
+	// by relevance. Repositories without one fall back to SearchSubstring.
+	SearchFullText SearchMode = "fulltext"
+	// SearchSubstring matches the text anywhere in the title, content, excerpt or tags
+	SearchSubstring SearchMode = "substring"
+)
+
+// PostQuery holds the filters and pagination used when listing posts
+type PostQuery struct {
+	Page       int
+	Limit      int
+	Search     string
+	SearchMode SearchMode
+	Category   string
+	Tag        string
+	Author     string
+	Year       int
+	Featured   bool
+}
+
+// Offset returns the number of rows to skip for the requested page
+func (q PostQuery) Offset() int {
+	return (q.Page - 1) * q.Limit
+}
+
+// PostRepository is the read side of the post store used by the public GET routes
+type PostRepository interface {
+	// ListPublished returns one page of published posts and the total number of matches
+	ListPublished(ctx context.Context, q PostQuery) ([]models.BlogPost, int64, error)
+
+	// Facets counts the published posts matching q by category, tag, author
+	// and publish year; q's pagination is ignored
+	Facets(ctx context.Context, q PostQuery) (models.SearchFacets, error)
+
+	// GetPublishedBySlug returns the published post with the given slug
+	GetPublishedBySlug(ctx context.Context, slug string) (*models.BlogPost, error)
+
+	// ListTags returns every tag used by a published post with its post count,
+	// most used first
+	ListTags(ctx context.Context) ([]models.TagCount, error)
+
+	// GetTag returns the tag with the given slug
+	GetTag(ctx context.Context, tagSlug string) (*models.Tag, error)
+
+	// ListCategories returns every category with the number of published
+	// posts in it and its descendants, sorted by name
+	ListCategories(ctx context.Context) ([]models.CategoryResponse, error)
+
+	// ResolveSlug returns the current slug of the published post that used to
+	// be reachable under oldSlug
+	ResolveSlug(ctx context.Context, oldSlug string) (string, error)
+}
@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// fullTextEngine is the full-text search implementation available in a database
+type fullTextEngine string
+
+const (
+	fullTextNone     fullTextEngine = ""
+	fullTextPostgres fullTextEngine = "postgres"
+	fullTextFTS5     fullTextEngine = "fts5"
+)
+
+// postgresSearchVector must stay identical to the expression indexed by
+// idx_blog_posts_search, otherwise Postgres cannot use the GIN index
+const postgresSearchVector = "to_tsvector('english', blog_posts.title || ' ' || blog_posts.content || ' ' || blog_posts.excerpt || ' ' || COALESCE(blog_posts.tags, ''))"
+
+// postgresHeadlineOptions configures the ts_headline snippets returned with results
+const postgresHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=\" … \""
+
+// EnsureSearchIndex creates the full-text index for db's dialect. On Postgres
+// that is the GIN index from migrations/init.sql. On SQLite it is an FTS5 table
+// kept in sync with blog_posts by triggers, which needs go-sqlite3 built with
+// the sqlite_fts5 tag; without it an error is returned and searches fall back
+// to substring matching.
+func EnsureSearchIndex(db *gorm.DB) error {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return db.Exec("CREATE INDEX IF NOT EXISTS idx_blog_posts_search ON blog_posts USING gin(" +
+			"to_tsvector('english', title || ' ' || content || ' ' || excerpt || ' ' || COALESCE(tags, '')))").Error
+	case "sqlite":
+		return ensureFTS5(db)
+	}
+	return nil
+}
+
+func ensureFTS5(db *gorm.DB) error {
+	if detectFullText(db) == fullTextFTS5 {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			`CREATE VIRTUAL TABLE blog_posts_fts USING fts5(
+				title, content, excerpt, tags,
+				content='blog_posts', content_rowid='id', tokenize='porter unicode61'
+			)`,
+			`CREATE TRIGGER blog_posts_fts_insert AFTER INSERT ON blog_posts BEGIN
+				INSERT INTO blog_posts_fts(rowid, title, content, excerpt, tags)
+				VALUES (new.id, new.title, new.content, new.excerpt, new.tags);
+			END`,
+			`CREATE TRIGGER blog_posts_fts_delete AFTER DELETE ON blog_posts BEGIN
+				INSERT INTO blog_posts_fts(blog_posts_fts, rowid, title, content, excerpt, tags)
+				VALUES ('delete', old.id, old.title, old.content, old.excerpt, old.tags);
+			END`,
+			`CREATE TRIGGER blog_posts_fts_update AFTER UPDATE ON blog_posts BEGIN
+				INSERT INTO blog_posts_fts(blog_posts_fts, rowid, title, content, excerpt, tags)
+				VALUES ('delete', old.id, old.title, old.content, old.excerpt, old.tags);
+				INSERT INTO blog_posts_fts(rowid, title, content, excerpt, tags)
+				VALUES (new.id, new.title, new.content, new.excerpt, new.tags);
+			END`,
+			`INSERT INTO blog_posts_fts(blog_posts_fts) VALUES ('rebuild')`,
+		}
+		for _, stmt := range statements {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return fmt.Errorf("creating the FTS5 index (is the server built with -tags sqlite_fts5?): %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// detectFullText reports which full-text engine db offers
+func detectFullText(db *gorm.DB) fullTextEngine {
+	switch db.Dialector.Name() {
+	case "postgres":
+		return fullTextPostgres
+	case "sqlite":
+		var count int64
+		if err := db.Raw("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'blog_posts_fts'").
+			Scan(&count).Error; err != nil {
+			log.Println("Could not check for the FTS5 search table:", err)
+			return fullTextNone
+		}
+		if count > 0 {
+			return fullTextFTS5
+		}
+	}
+	return fullTextNone
+}
+
+// String describes the engine for the startup log
+func (e fullTextEngine) String() string {
+	switch e {
+	case fullTextPostgres:
+		return "PostgreSQL full-text search"
+	case fullTextFTS5:
+		return "SQLite FTS5"
+	}
+	return "substring matching"
+}
+
+// match restricts query to posts matching search. It returns false when the
+// search cannot match anything, such as a query made only of exclusions.
+func (e fullTextEngine) match(query *gorm.DB, search string) (*gorm.DB, bool) {
+	switch e {
+	case fullTextPostgres:
+		return query.Where(postgresSearchVector+" @@ websearch_to_tsquery('english', ?)", search), true
+	case fullTextFTS5:
+		expr := toFTS5Query(search)
+		if expr == "" {
+			return query, false
+		}
+		return query.
+			Joins("JOIN blog_posts_fts ON blog_posts_fts.rowid = blog_posts.id").
+			Where("blog_posts_fts MATCH ?", expr), true
+	}
+	return query, true
+}
+
+// rank selects the relevance score and headline for each match and orders by relevance
+func (e fullTextEngine) rank(query *gorm.DB, search string) *gorm.DB {
+	switch e {
+	case fullTextPostgres:
+		return query.
+			Select("blog_posts.*, "+
+				"ts_rank("+postgresSearchVector+", websearch_to_tsquery('english', ?)) AS search_rank, "+
+				"ts_headline('english', regexp_replace(blog_posts.content, '<[^>]*>', ' ', 'g'), websearch_to_tsquery('english', ?), ?) AS search_headline",
+				search, search, postgresHeadlineOptions).
+			Order("search_rank DESC")
+	case fullTextFTS5:
+		// bm25 is lower for better matches; titles weigh most, then tags
+		return query.
+			Select("blog_posts.*, " +
+				"-bm25(blog_posts_fts, 10.0, 1.0, 2.0, 5.0) AS search_rank, " +
+				"snippet(blog_posts_fts, -1, '<mark>', '</mark>', ' … ', 24) AS search_headline").
+			Order("search_rank DESC")
+	}
+	return query
+}
+
+var (
+	snippetTag          = regexp.MustCompile(`<[^>]*>`)
+	snippetLeadingPart  = regexp.MustCompile(`^[^<]*?>`)
+	snippetTrailingPart = regexp.MustCompile(`<[^>]*$`)
+	snippetSpaces       = regexp.MustCompile(`\s+`)
+)
+
+// cleanSnippet strips the post's own HTML from an FTS5 snippet, which is cut
+// from raw content, keeping only the <mark> tags around matches
+func cleanSnippet(snippet string) string {
+	snippet = strings.NewReplacer("<mark>", "\x00", "</mark>", "\x01").Replace(snippet)
+	snippet = snippetLeadingPart.ReplaceAllString(snippet, "")
+	snippet = snippetTrailingPart.ReplaceAllString(snippet, "")
+	snippet = snippetTag.ReplaceAllString(snippet, " ")
+	snippet = snippetSpaces.ReplaceAllString(snippet, " ")
+	return strings.NewReplacer("\x00", "<mark>", "\x01", "</mark>").Replace(strings.TrimSpace(snippet))
+}
+
+// toFTS5Query translates websearch_to_tsquery syntax ("quoted phrases",
+// -excluded words and OR) into an FTS5 MATCH expression. Every term is quoted
+// so user input can never be parsed as FTS5 operators.
+func toFTS5Query(search string) string {
+	var include, exclude []string
+	pendingOr := false
+
+	for _, term := range splitSearchTerms(search) {
+		switch {
+		case term.text == "":
+			continue
+		case !term.quoted && strings.EqualFold(term.text, "or"):
+			pendingOr = len(include) > 0
+			continue
+		}
+
+		phrase := `"` + strings.ReplaceAll(term.text, `"`, `""`) + `"`
+		if term.negated {
+			exclude = append(exclude, phrase)
+			continue
+		}
+		if pendingOr {
+			include = append(include, "OR")
+			pendingOr = false
+		}
+		include = append(include, phrase)
+	}
+
+	if len(include) == 0 {
+		return ""
+	}
+
+	expr := "(" + strings.Join(include, " ") + ")"
+	for _, phrase := range exclude {
+		expr += " NOT " + phrase
+	}
+	return expr
+}
+
+type searchTerm struct {
+	text    string
+	quoted  bool
+	negated bool
+}
+
+// splitSearchTerms splits a search string on whitespace, keeping "quoted
+// phrases" together and noting a leading minus on each term
+func splitSearchTerms(search string) []searchTerm {
+	var terms []searchTerm
+	rest := strings.TrimSpace(search)
+
+	for rest != "" {
+		term := searchTerm{}
+		if strings.HasPrefix(rest, "-") {
+			term.negated = true
+			rest = rest[1:]
+		}
+
+		if strings.HasPrefix(rest, `"`) {
+			term.quoted = true
+			rest = rest[1:]
+			end := strings.IndexByte(rest, '"')
+			if end < 0 {
+				end = len(rest)
+			}
+			term.text = strings.TrimSpace(rest[:end])
+			rest = rest[min(end+1, len(rest)):]
+		} else {
+			end := strings.IndexAny(rest, " \t\n\r")
+			if end < 0 {
+				end = len(rest)
+			}
+			term.text = rest[:end]
+			rest = rest[end:]
+		}
+
+		terms = append(terms, term)
+		rest = strings.TrimSpace(rest)
+	}
+
+	return terms
+}
@@ -0,0 +1,85 @@
+//go:build sqlite_fts5
+
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"blogapp/internals/models"
+)
+
+// Run with go test -tags sqlite_fts5 (make test), which compiles FTS5 into
+// the SQLite driver
+func TestFTS5Search(t *testing.T) {
+	db := openTestDB(t)
+	if err := EnsureSearchIndex(db); err != nil {
+		t.Fatal(err)
+	}
+	repo := NewGormPostRepository(db)
+	if got := repo.SearchBackend(); got != "SQLite FTS5" {
+		t.Fatalf("SearchBackend() = %q, want %q", got, "SQLite FTS5")
+	}
+
+	publishedAt := time.Now().Add(-time.Hour)
+	for _, post := range []models.BlogPost{
+		{Title: "Keyboard navigation", Slug: "keyboard", Content: "<p>Every control must be reachable by keyboard, and focus needs enough contrast to be seen.</p>"},
+		{Title: "Colour contrast", Slug: "contrast", Content: "<p>Text needs a <strong>contrast</strong> ratio of at least 4.5:1.</p>"},
+		{Title: "Alt text", Slug: "alt-text", Content: "<p>Describe what an image shows.</p>"},
+	} {
+		post.ContentFormat = models.FormatHTML
+		post.Status = models.StatusPublished
+		post.Published = true
+		post.PublishedAt = &publishedAt
+		if err := db.Create(&post).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	search := func(q string) []models.BlogPost {
+		t.Helper()
+		posts, _, err := repo.ListPublished(context.Background(), PostQuery{Page: 1, Limit: 10, Search: q})
+		if err != nil {
+			t.Fatalf("search %q: %v", q, err)
+		}
+		return posts
+	}
+	slugs := func(posts []models.BlogPost) string {
+		var s []string
+		for _, post := range posts {
+			s = append(s, post.Slug)
+		}
+		return strings.Join(s, ",")
+	}
+
+	// The title match outranks the passing mention
+	posts := search("contrast")
+	if got := slugs(posts); got != "contrast,keyboard" {
+		t.Fatalf("search contrast = %s, want contrast,keyboard", got)
+	}
+	if headline := posts[0].SearchHeadline; !strings.Contains(headline, "<mark>contrast</mark>") || strings.Contains(headline, "<strong>") {
+		t.Errorf("headline = %q, want a marked match without the post's markup", headline)
+	}
+
+	tests := []struct{ search, want string }{
+		{"contrast -keyboard", "contrast"},
+		{`"keyboard navigation"`, "keyboard"},
+		{"image or ratio", "alt-text,contrast"},
+		{"-contrast", ""},
+	}
+	for _, tt := range tests {
+		if got := slugs(search(tt.search)); got != tt.want {
+			t.Errorf("search %q = %s, want %s", tt.search, got, tt.want)
+		}
+	}
+
+	// The triggers keep the index in step with edits
+	if err := db.Model(&models.BlogPost{}).Where("slug = ?", "alt-text").Update("title", "Alternative descriptions").Error; err != nil {
+		t.Fatal(err)
+	}
+	if got := slugs(search("alternative")); got != "alt-text" {
+		t.Errorf("search alternative after rename = %s, want alt-text", got)
+	}
+}
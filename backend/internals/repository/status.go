@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"blogapp/internals/models"
+)
+
+// liveCondition matches the posts readers can see: published and not dated in
+// the future. It takes the published flag and the current time as arguments.
+const liveCondition = "blog_posts.published = ? AND (blog_posts.published_at IS NULL OR blog_posts.published_at <= ?)"
+
+// Live restricts query to posts readers can see right now. Handlers counting
+// or listing posts for the public use it too, so they agree with the repository.
+func Live(query *gorm.DB) *gorm.DB {
+	return query.Where(liveCondition, true, time.Now())
+}
+
+// MigrateStatuses gives posts stored before the status column existed the
+// status matching their published flag. New rows start as drafts, so only
+// published ones need updating.
+func MigrateStatuses(db *gorm.DB) error {
+	return db.Model(&models.BlogPost{}).
+		Where("published = ? AND status = ?", true, models.StatusDraft).
+		UpdateColumn("status", models.StatusPublished).Error
+}
+
+// PublishDue publishes every scheduled post whose publish date has passed at
+// now and returns how many went live. With requireApproval set, posts whose
+// current version has no approved review stay scheduled.
+func PublishDue(db *gorm.DB, now time.Time, requireApproval bool) (int64, error) {
+	query := db.Model(&models.BlogPost{}).
+		Where("status = ? AND published_at <= ?", models.StatusScheduled, now)
+	if requireApproval {
+		query = query.Where("EXISTS (SELECT 1 FROM reviews WHERE reviews.post_id = blog_posts.id AND reviews.state = ? AND reviews.post_version = blog_posts.version)",
+			models.ReviewApproved)
+	}
+	result := query.
+		UpdateColumns(map[string]interface{}{
+			"status":     models.StatusPublished,
+			"published":  true,
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": now,
+		})
+	return result.RowsAffected, result.Error
+}
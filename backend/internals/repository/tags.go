@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"blogapp/internals/models"
+)
+
+// ErrTagNotFound is returned when no tag has the requested slug
+var ErrTagNotFound = errors.New("tag not found")
+
+// FindOrCreateTags returns the stored tags with the given names, creating any
+// that do not exist yet. Names are matched by slug, so existing tags keep
+// their original spelling.
+func FindOrCreateTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	tags := models.NewTags(names)
+	if len(tags) == 0 {
+		return tags, nil
+	}
+
+	// Another request may create the same tag concurrently; the unique slug
+	// index makes that a no-op and the reload below picks up its row
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "slug"}},
+		DoNothing: true,
+	}).Create(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	slugs := make([]string, len(tags))
+	for i, tag := range tags {
+		slugs[i] = tag.Slug
+	}
+
+	var stored []models.Tag
+	if err := tx.Where("slug IN ?", slugs).Find(&stored).Error; err != nil {
+		return nil, err
+	}
+
+	bySlug := make(map[string]models.Tag, len(stored))
+	for _, tag := range stored {
+		bySlug[tag.Slug] = tag
+	}
+
+	result := make([]models.Tag, 0, len(slugs))
+	for _, s := range slugs {
+		if tag, ok := bySlug[s]; ok {
+			result = append(result, tag)
+		}
+	}
+	return result, nil
+}
+
+// MigrateTags moves tags still held only in the old comma-separated
+// blog_posts.tags column into the tags and post_tags tables. Posts that
+// already have post_tags rows are skipped, so it is safe to run on every start.
+func MigrateTags(db *gorm.DB) error {
+	var posts []models.BlogPost
+	if err := db.Select("id", "tags").
+		Where("tags IS NOT NULL AND tags <> ''").
+		Where("NOT EXISTS (SELECT 1 FROM post_tags WHERE post_tags.post_id = blog_posts.id)").
+		Find(&posts).Error; err != nil {
+		return err
+	}
+
+	for _, post := range posts {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			tags, err := FindOrCreateTags(tx, models.ParseTags(post.TagNames))
+			if err != nil {
+				return err
+			}
+			if len(tags) == 0 {
+				return nil
+			}
+
+			rows := make([]models.PostTag, len(tags))
+			for i, tag := range tags {
+				rows[i] = models.PostTag{PostID: post.ID, TagID: tag.ID}
+			}
+			if err := tx.Omit(clause.Associations).Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error; err != nil {
+				return err
+			}
+
+			// Rewrite the search copy so it matches the deduplicated tags
+			post.SetTags(tags)
+			return tx.Model(&models.BlogPost{}).Where("id = ?", post.ID).UpdateColumn("tags", post.TagNames).Error
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ListTags implements PostRepository
+func (r *GormPostRepository) ListTags(ctx context.Context) ([]models.TagCount, error) {
+	counts := []models.TagCount{}
+	err := r.db.WithContext(ctx).
+		Table("tags").
+		Select("tags.name, tags.slug, COUNT(*) AS post_count").
+		Joins("JOIN post_tags ON post_tags.tag_id = tags.id").
+		Joins("JOIN blog_posts ON blog_posts.id = post_tags.post_id").
+		Where("blog_posts.deleted_at IS NULL").
+		Where(liveCondition, true, time.Now()).
+		Group("tags.id, tags.name, tags.slug").
+		Order("post_count DESC, tags.name").
+		Scan(&counts).Error
+	return counts, err
+}
+
+// GetTag implements PostRepository
+func (r *GormPostRepository) GetTag(ctx context.Context, tagSlug string) (*models.Tag, error) {
+	var tag models.Tag
+	if err := r.db.WithContext(ctx).Where("slug = ?", tagSlug).First(&tag).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTagNotFound
+		}
+		return nil, err
+	}
+	return &tag, nil
+}
+
+// ListTags implements PostRepository
+func (r *MemoryPostRepository) ListTags(ctx context.Context) ([]models.TagCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := []models.TagCount{}
+	index := make(map[string]int)
+	now := time.Now()
+	for _, post := range r.posts {
+		if !post.IsLive(now) {
+			continue
+		}
+		for _, tag := range post.Tags {
+			i, ok := index[tag.Slug]
+			if !ok {
+				i = len(counts)
+				index[tag.Slug] = i
+				counts = append(counts, models.TagCount{Name: tag.Name, Slug: tag.Slug})
+			}
+			counts[i].PostCount++
+		}
+	}
+
+	sortTagCounts(counts)
+	return counts, nil
+}
+
+// GetTag implements PostRepository
+func (r *MemoryPostRepository) GetTag(ctx context.Context, tagSlug string) (*models.Tag, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, post := range r.posts {
+		for _, tag := range post.Tags {
+			if tag.Slug == tagSlug {
+				found := tag
+				return &found, nil
+			}
+		}
+	}
+	return nil, ErrTagNotFound
+}
+
+// sortTagCounts orders tags like ListTags does in SQL: most used first, then by name
+func sortTagCounts(counts []models.TagCount) {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].PostCount != counts[j].PostCount {
+			return counts[i].PostCount > counts[j].PostCount
+		}
+		return counts[i].Name < counts[j].Name
+	})
+}
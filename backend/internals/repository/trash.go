@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"blogapp/internals/models"
+)
+
+// PurgeTrash permanently deletes posts that were moved to the trash before
+// cutoff. Their tags links, revisions and old slugs go with them through the
+// foreign keys. It returns the number of posts removed.
+func PurgeTrash(db *gorm.DB, cutoff time.Time) (int64, error) {
+	result := db.Unscoped().
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+		Delete(&models.BlogPost{})
+	return result.RowsAffected, result.Error
+}
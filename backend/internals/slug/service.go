@@ -0,0 +1,148 @@
+package slug
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+// ErrSlugTaken is returned by Rename when another post uses, or used to use, the slug
+var ErrSlugTaken = errors.New("slug is already in use")
+
+// maxAttempts bounds how often Create retries after losing a race for a slug
+const maxAttempts = 5
+
+// Service hands out slugs that are unique within the blog_posts table
+type Service struct {
+	db *gorm.DB
+}
+
+func NewService(db *gorm.DB) *Service {
+	return &Service{db: db}
+}
+
+// Create derives a slug from title, makes it unique by appending -2, -3, ...
+// and calls insert with it inside a transaction. On Postgres the candidate is
+// reserved with a transaction-scoped advisory lock on the base slug, so
+// concurrent creators of the same title queue up instead of colliding. SQLite
+// has no advisory locks, so the database must be opened with _txlock=immediate
+// for its transactions to queue the same way. If an insert still hits the
+// unique constraint it is retried with a fresh candidate.
+func (s *Service) Create(ctx context.Context, title string, insert func(tx *gorm.DB, slug string) error) error {
+	base := Make(title)
+
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := lockBase(tx, base); err != nil {
+				return err
+			}
+
+			candidate, err := Available(tx, base, 0)
+			if err != nil {
+				return err
+			}
+			return insert(tx, candidate)
+		})
+		if err == nil || !IsUniqueViolation(err) {
+			return err
+		}
+	}
+	return err
+}
+
+// Available returns base if no post other than excludeID uses it, otherwise
+// base-N with the smallest free N starting at 2. Slugs kept in slug_history
+// for other posts count as used so that old links never change target.
+func Available(tx *gorm.DB, base string, excludeID uint) (string, error) {
+	var current, previous []string
+	if err := tx.Table("blog_posts").
+		Where("(slug = ? OR slug LIKE ?) AND id <> ?", base, base+"-%", excludeID).
+		Pluck("slug", &current).Error; err != nil {
+		return "", err
+	}
+	if err := tx.Table("slug_history").
+		Where("(slug = ? OR slug LIKE ?) AND post_id <> ?", base, base+"-%", excludeID).
+		Pluck("slug", &previous).Error; err != nil {
+		return "", err
+	}
+
+	used := make(map[int]bool, len(current)+len(previous))
+	for _, slug := range append(current, previous...) {
+		if slug == base {
+			used[1] = true
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(slug, base+"-")); err == nil && n >= 2 {
+			used[n] = true
+		}
+	}
+
+	if !used[1] {
+		return base, nil
+	}
+	for n := 2; ; n++ {
+		if !used[n] {
+			return base + "-" + strconv.Itoa(n), nil
+		}
+	}
+}
+
+// Rename moves post postID from currentSlug to requested, which is normalised
+// with Make first. The old slug is kept in slug_history so it can redirect to
+// the post; a post may take back one of its own old slugs. It must run inside
+// the transaction that saves the post and returns the slug actually assigned.
+func (s *Service) Rename(tx *gorm.DB, postID uint, currentSlug, requested string) (string, error) {
+	newSlug := Make(requested)
+	if newSlug == currentSlug {
+		return currentSlug, nil
+	}
+
+	if err := lockBase(tx, newSlug); err != nil {
+		return "", err
+	}
+
+	candidate, err := Available(tx, newSlug, postID)
+	if err != nil {
+		return "", err
+	}
+	if candidate != newSlug {
+		return "", ErrSlugTaken
+	}
+
+	if err := tx.Exec("DELETE FROM slug_history WHERE post_id = ? AND slug = ?", postID, newSlug).Error; err != nil {
+		return "", err
+	}
+	if err := tx.Exec("INSERT INTO slug_history (post_id, slug, created_at) VALUES (?, ?, ?)",
+		postID, currentSlug, time.Now()).Error; err != nil {
+		return "", err
+	}
+
+	return newSlug, nil
+}
+
+// IsUniqueViolation reports whether err came from a unique constraint
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505"
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "duplicate key") || strings.Contains(msg, "UNIQUE constraint failed")
+}
+
+// lockBase serialises slug allocation for one base slug until the transaction ends
+func lockBase(tx *gorm.DB, base string) error {
+	if tx.Dialector.Name() != "postgres" {
+		return nil
+	}
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "blog_posts.slug:"+base).Error
+}
@@ -0,0 +1,199 @@
+package slug
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength is the longest slug Make returns. It leaves room in the 255
+// character slug column for a numeric collision suffix.
+const MaxLength = 200
+
+// KeyMaxLength is the most characters Key returns, the size of the tag and
+// category slug columns
+const KeyMaxLength = 100
+
+// keySeparators split words in a name without changing what it names, so
+// "Node.js", "node js" and "node-js" share a key
+const keySeparators = " \t\n-_./,:;'’"
+
+// fallback is used when a title has no characters that can be transliterated
+const fallback = "post"
+
+// transliterations covers letters that do not decompose into an ASCII base
+// letter plus combining marks under NFKD
+var transliterations = map[rune]string{
+	// Latin
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'þ': "th",
+	'ł': "l", 'ı': "i", 'ŋ': "ng", 'ĸ': "k", 'ſ': "s",
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i", 'θ': "th",
+	'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x", 'ο': "o", 'π': "p",
+	'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y", 'φ': "f", 'χ': "ch", 'ψ': "ps",
+	'ω': "o",
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya", 'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g",
+	// Symbols that carry meaning in titles
+	'&': " and ", '@': " at ", '+': " plus ",
+}
+
+// Make converts a title into a lowercase ASCII slug such as "cafe-creme".
+// Accented letters lose their accents, Greek and Cyrillic are transliterated
+// and anything else that is not a letter or digit becomes a single hyphen.
+func Make(title string) string {
+	return MakeOr(title, fallback)
+}
+
+// MakeOr is Make with the slug to use when title has nothing to transliterate
+func MakeOr(title, fallbackSlug string) string {
+	var b strings.Builder
+	pendingHyphen := false
+
+	write := func(s string) {
+		for _, r := range s {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+				if pendingHyphen && b.Len() > 0 {
+					b.WriteByte('-')
+				}
+				pendingHyphen = false
+				b.WriteRune(r)
+			} else {
+				pendingHyphen = true
+			}
+		}
+	}
+
+	for _, r := range title {
+		r = unicode.ToLower(r)
+		if t, ok := transliterations[r]; ok {
+			write(t)
+			continue
+		}
+
+		// Decompose one rune at a time so letters such as "й" hit the
+		// table above before losing their marks
+		for _, d := range norm.NFKD.String(string(r)) {
+			if unicode.Is(unicode.Mn, d) {
+				continue
+			}
+			d = unicode.ToLower(d)
+			if t, ok := transliterations[d]; ok {
+				write(t)
+				continue
+			}
+			write(string(d))
+		}
+	}
+
+	slug := b.String()
+	if len(slug) > MaxLength {
+		slug = slug[:MaxLength]
+		if i := strings.LastIndexByte(slug, '-'); i > MaxLength/2 {
+			slug = slug[:i]
+		}
+		slug = strings.Trim(slug, "-")
+	}
+
+	if slug == "" {
+		return fallbackSlug
+	}
+	return slug
+}
+
+// Key converts a tag or category name into the slug that identifies it. It
+// is Make, except that letters with no ASCII spelling, such as Chinese,
+// Japanese or Korean, are kept as they are, and a name that loses symbols
+// other than separators, such as "C#", ends in a hash of the whole name so it
+// cannot take the key of "C". Key is idempotent, so a key can be looked up by
+// passing it back in.
+func Key(name string) string {
+	var b strings.Builder
+	pendingHyphen := false
+	lossy := false
+
+	write := func(r rune) {
+		if pendingHyphen && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		pendingHyphen = false
+		b.WriteRune(r)
+	}
+	writeASCII := func(s string) {
+		for _, r := range s {
+			if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+				write(r)
+			} else {
+				pendingHyphen = true
+			}
+		}
+	}
+
+	for _, r := range norm.NFC.String(name) {
+		r = unicode.ToLower(r)
+		if t, ok := transliterations[r]; ok {
+			writeASCII(t)
+			continue
+		}
+
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			// Accented Latin letters lose their accents; letters of other
+			// scripts are kept whole, as their marks may tell words apart
+			if ascii, ok := asciiBase(r); ok {
+				writeASCII(ascii)
+			} else {
+				write(r)
+			}
+		case strings.ContainsRune(keySeparators, r):
+			pendingHyphen = true
+		default:
+			pendingHyphen = true
+			lossy = true
+		}
+	}
+
+	key := b.String()
+	limit := KeyMaxLength
+	if lossy || key == "" {
+		limit -= 1 + 8
+	}
+	if utf8.RuneCountInString(key) > limit {
+		key = strings.TrimRight(string([]rune(key)[:limit]), "-")
+	}
+	if lossy || key == "" {
+		sum := sha256.Sum256([]byte(strings.ToLower(norm.NFC.String(strings.TrimSpace(name)))))
+		key = strings.TrimLeft(key+"-"+hex.EncodeToString(sum[:4]), "-")
+	}
+	return key
+}
+
+// asciiBase returns the ASCII letters and digits r decomposes into under
+// NFKD once its combining marks are dropped, and false if any other
+// character is left
+func asciiBase(r rune) (string, bool) {
+	var b strings.Builder
+	for _, d := range norm.NFKD.String(string(r)) {
+		if unicode.Is(unicode.Mn, d) {
+			continue
+		}
+		d = unicode.ToLower(d)
+		if t, ok := transliterations[d]; ok {
+			b.WriteString(t)
+			continue
+		}
+		if d >= utf8.RuneSelf {
+			return "", false
+		}
+		b.WriteRune(d)
+	}
+	return b.String(), true
+}
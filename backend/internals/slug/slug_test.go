@@ -0,0 +1,277 @@
+package slug
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct{ title, want string }{
+		{"Hello, World!", "hello-world"},
+		{"  Leading and trailing  ", "leading-and-trailing"},
+		{"Café Crème", "cafe-creme"},
+		{"Straße", "strasse"},
+		{"Łódź", "lodz"},
+		{"Ærøskøbing", "aeroskobing"},
+		{"Привет, мир", "privet-mir"},
+		{"Йога", "yoga"},
+		{"Αθήνα", "athina"},
+		{"Tom & Jerry", "tom-and-jerry"},
+		{"C++ tips", "c-plus-plus-tips"},
+		{"ﬁle", "file"},
+		{"日本語", "post"},
+		{"!!!", "post"},
+	}
+
+	for _, tt := range tests {
+		if got := Make(tt.title); got != tt.want {
+			t.Errorf("Make(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+
+	if got := MakeOr("日本語", "untitled"); got != "untitled" {
+		t.Errorf("MakeOr(%q, %q) = %q", "日本語", "untitled", got)
+	}
+
+	long := Make(strings.Repeat("word ", 100))
+	if len(long) > MaxLength || strings.HasSuffix(long, "-") || strings.HasSuffix(long, "wor") {
+		t.Errorf("Make(long title) = %q, want at most %d characters ending on a whole word", long, MaxLength)
+	}
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct{ name, want string }{
+		{"Node.js", "node-js"},
+		{"node js", "node-js"},
+		{"Café", "cafe"},
+		{"Москва", "moskva"},
+		{"日本語", "日本語"},
+		{"Go 言語", "go-言語"},
+		{"C#", "c-951a4d36"},
+		{"!!!", "e84c538e"},
+	}
+
+	for _, tt := range tests {
+		if got := Key(tt.name); got != tt.want {
+			t.Errorf("Key(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+		if got := Key(tt.want); got != tt.want {
+			t.Errorf("Key(%q) = %q, want it unchanged", tt.want, got)
+		}
+	}
+
+	if long := Key(strings.Repeat("語", 150)); len([]rune(long)) > KeyMaxLength {
+		t.Errorf("Key(long name) has %d characters, want at most %d", len([]rune(long)), KeyMaxLength)
+	}
+}
+
+// openDB returns a SQLite database holding just the columns the service uses,
+// opened the way the server opens it
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "slug.db")
+	db, err := gorm.Open(sqlite.Open(path+"?_busy_timeout=5000&_txlock=immediate"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE blog_posts (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT NOT NULL, slug TEXT NOT NULL UNIQUE)`,
+		`CREATE TABLE slug_history (id INTEGER PRIMARY KEY AUTOINCREMENT, post_id INTEGER NOT NULL, slug TEXT NOT NULL, created_at DATETIME)`,
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return db
+}
+
+// createPost inserts a post through the service and returns its slug
+func createPost(t *testing.T, s *Service, title string) string {
+	t.Helper()
+
+	var assigned string
+	err := s.Create(context.Background(), title, func(tx *gorm.DB, slug string) error {
+		assigned = slug
+		return tx.Exec("INSERT INTO blog_posts (title, slug) VALUES (?, ?)", title, slug).Error
+	})
+	if err != nil {
+		t.Fatalf("Create(%q): %v", title, err)
+	}
+	return assigned
+}
+
+func TestCreateAddsSuffix(t *testing.T) {
+	s := NewService(openDB(t))
+
+	for _, want := range []string{"hello-world", "hello-world-2", "hello-world-3"} {
+		if got := createPost(t, s, "Hello, World!"); got != want {
+			t.Errorf("Create = %q, want %q", got, want)
+		}
+	}
+	// A longer slug that merely starts with the base does not count
+	if got := createPost(t, s, "Hello world again"); got != "hello-world-again" {
+		t.Errorf("Create = %q, want %q", got, "hello-world-again")
+	}
+}
+
+func TestAvailable(t *testing.T) {
+	db := openDB(t)
+	for _, stmt := range []string{
+		`INSERT INTO blog_posts (id, title, slug) VALUES (1, 'a', 'guide'), (2, 'b', 'guide-3'), (3, 'c', 'guide-book')`,
+		`INSERT INTO slug_history (post_id, slug) VALUES (4, 'guide-2'), (1, 'guide-4')`,
+	} {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		base      string
+		excludeID uint
+		want      string
+	}{
+		{"new", 0, "new"},
+		// guide-2 is an old slug of post 4 and must keep redirecting there
+		{"guide", 0, "guide-5"},
+		// A post may keep its own slug or take back one of its old ones
+		{"guide", 1, "guide"},
+		{"guide-4", 1, "guide-4"},
+		{"guide-2", 4, "guide-2"},
+		{"guide-2", 0, "guide-2-2"},
+	}
+
+	for _, tt := range tests {
+		got, err := Available(db, tt.base, tt.excludeID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("Available(%q, %d) = %q, want %q", tt.base, tt.excludeID, got, tt.want)
+		}
+	}
+}
+
+func TestRename(t *testing.T) {
+	db := openDB(t)
+	s := NewService(db)
+	createPost(t, s, "First post")
+	createPost(t, s, "Second post")
+
+	rename := func(postID uint, current, requested string) (string, error) {
+		var slug string
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			if slug, err = s.Rename(tx, postID, current, requested); err != nil {
+				return err
+			}
+			return tx.Exec("UPDATE blog_posts SET slug = ? WHERE id = ?", slug, postID).Error
+		})
+		return slug, err
+	}
+	history := func(postID uint) string {
+		var slugs []string
+		if err := db.Table("slug_history").Where("post_id = ?", postID).Order("slug").Pluck("slug", &slugs).Error; err != nil {
+			t.Fatal(err)
+		}
+		return strings.Join(slugs, ",")
+	}
+
+	if got, err := rename(1, "first-post", "First post"); err != nil || got != "first-post" {
+		t.Fatalf("Rename to the same slug = %q, %v", got, err)
+	}
+	if got := history(1); got != "" {
+		t.Errorf("history after no-op rename = %q, want none", got)
+	}
+
+	if got, err := rename(1, "first-post", "The Opening Post"); err != nil || got != "the-opening-post" {
+		t.Fatalf("Rename = %q, %v, want %q", got, err, "the-opening-post")
+	}
+	if got := history(1); got != "first-post" {
+		t.Errorf("history = %q, want %q", got, "first-post")
+	}
+
+	// Another post's current and old slugs are both taken
+	for _, requested := range []string{"the-opening-post", "first-post"} {
+		if _, err := rename(2, "second-post", requested); !errors.Is(err, ErrSlugTaken) {
+			t.Errorf("Rename to %q = %v, want ErrSlugTaken", requested, err)
+		}
+	}
+
+	// Taking back an old slug moves the current one into the history
+	if got, err := rename(1, "the-opening-post", "first-post"); err != nil || got != "first-post" {
+		t.Fatalf("Rename back = %q, %v", got, err)
+	}
+	if got := history(1); got != "the-opening-post" {
+		t.Errorf("history = %q, want %q", got, "the-opening-post")
+	}
+}
+
+// Posts created at the same moment with the same title must still get
+// different slugs
+func TestCreateConcurrently(t *testing.T) {
+	s := NewService(openDB(t))
+
+	const creators = 8
+	slugs := make([]string, creators)
+	errs := make([]error, creators)
+	var wg sync.WaitGroup
+	for i := 0; i < creators; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = s.Create(context.Background(), "Same title", func(tx *gorm.DB, slug string) error {
+				slugs[i] = slug
+				return tx.Exec("INSERT INTO blog_posts (title, slug) VALUES (?, ?)", "Same title", slug).Error
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[string]bool)
+	for i := range slugs {
+		if errs[i] != nil {
+			t.Errorf("creator %d: %v", i, errs[i])
+			continue
+		}
+		if seen[slugs[i]] {
+			t.Errorf("slug %q handed out twice", slugs[i])
+		}
+		seen[slugs[i]] = true
+	}
+}
+
+func TestCreateRetriesUniqueViolation(t *testing.T) {
+	s := NewService(openDB(t))
+
+	attempts := 0
+	err := s.Create(context.Background(), "Raced", func(tx *gorm.DB, slug string) error {
+		attempts++
+		if attempts == 1 {
+			// Another creator took the slug between the check and the insert
+			return errors.New("UNIQUE constraint failed: blog_posts.slug")
+		}
+		return tx.Exec("INSERT INTO blog_posts (title, slug) VALUES (?, ?)", "Raced", slug).Error
+	})
+	if err != nil || attempts != 2 {
+		t.Errorf("Create = %v after %d attempts, want success on the second", err, attempts)
+	}
+
+	failure := errors.New("disk full")
+	attempts = 0
+	err = s.Create(context.Background(), "Failed", func(tx *gorm.DB, slug string) error {
+		attempts++
+		return failure
+	})
+	if !errors.Is(err, failure) || attempts != 1 {
+		t.Errorf("Create = %v after %d attempts, want the error without a retry", err, attempts)
+	}
+}
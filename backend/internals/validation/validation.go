@@ -0,0 +1,130 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// FieldError describes one field that failed validation. Field is the JSON
+// name of the field, with a dotted path and index for nested values.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Errors is returned by Struct when one or more fields are invalid
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+var (
+	once     sync.Once
+	validate *validator.Validate
+)
+
+// instance returns the shared validator, which reports fields by their JSON names
+func instance() *validator.Validate {
+	once.Do(func() {
+		validate = validator.New(validator.WithRequiredStructEnabled())
+		validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	})
+	return validate
+}
+
+// Struct checks v against its validate struct tags. It returns Errors when a
+// field is invalid, or another error if v cannot be validated at all.
+func Struct(v interface{}) error {
+	err := instance().Struct(v)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	fieldErrs := make(Errors, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		field := fieldPath(fe.Namespace())
+		fieldErrs = append(fieldErrs, FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Message: message(field, fe),
+		})
+	}
+	return fieldErrs
+}
+
+// fieldPath drops the struct name from a validator namespace such as
+// "CreateBlogPostRequest.tags[2]"
+func fieldPath(namespace string) string {
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+// message turns a failed rule into a sentence a client can show next to the field
+func message(field string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("%s is required", field)
+	case "email":
+		return fmt.Sprintf("%s must be a valid email address", field)
+	case "min", "max", "len":
+		msg := fmt.Sprintf("%s must be %s %s", field, bound(fe.Tag()), fe.Param())
+		if u := unit(fe); u != "" {
+			if fe.Param() == "1" {
+				u = strings.TrimSuffix(u, "s")
+			}
+			msg += " " + u
+		}
+		return msg
+	case "oneof":
+		return fmt.Sprintf("%s must be one of: %s", field, strings.ReplaceAll(fe.Param(), " ", ", "))
+	}
+	return fmt.Sprintf("%s is invalid (%s)", field, fe.Tag())
+}
+
+func bound(tag string) string {
+	switch tag {
+	case "min":
+		return "at least"
+	case "max":
+		return "at most"
+	}
+	return "exactly"
+}
+
+// unit names what a length rule counts for the field's kind
+func unit(fe validator.FieldError) string {
+	switch fe.Kind() {
+	case reflect.String:
+		return "characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "items"
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type post struct {
+	Title   string   `json:"title" validate:"required,min=5,max=255"`
+	Content string   `json:"content" validate:"required,min=100"`
+	Format  string   `json:"content_format" validate:"omitempty,oneof=html markdown"`
+	Tags    []string `json:"tags" validate:"max=2,dive,max=10"`
+	Count   int      `validate:"min=1"`
+}
+
+func validPost() post {
+	return post{Title: "Valid title", Content: strings.Repeat("x", 100), Tags: []string{"go"}, Count: 1}
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*post)
+		want   Errors
+	}{
+		{"valid", func(p *post) {}, nil},
+		{"title missing", func(p *post) { p.Title = "" },
+			Errors{{"title", "required", "title is required"}}},
+		{"title too short", func(p *post) { p.Title = "Tiny" },
+			Errors{{"title", "min", "title must be at least 5 characters"}}},
+		{"content too short", func(p *post) { p.Content = strings.Repeat("x", 99) },
+			Errors{{"content", "min", "content must be at least 100 characters"}}},
+		{"unknown format", func(p *post) { p.Format = "rtf" },
+			Errors{{"content_format", "oneof", "content_format must be one of: html, markdown"}}},
+		{"tag too long", func(p *post) { p.Tags = []string{"go", strings.Repeat("t", 11)} },
+			Errors{{"tags[1]", "max", "tags[1] must be at most 10 characters"}}},
+		{"too many tags", func(p *post) { p.Tags = []string{"a", "b", "c"} },
+			Errors{{"tags", "max", "tags must be at most 2 items"}}},
+		{"field without a JSON name", func(p *post) { p.Count = 0 },
+			Errors{{"Count", "min", "Count must be at least 1"}}},
+		{"every field reported", func(p *post) { p.Title, p.Content = "Tiny", "" },
+			Errors{{"title", "min", "title must be at least 5 characters"}, {"content", "required", "content is required"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := validPost()
+			tt.modify(&p)
+
+			err := Struct(&p)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Struct = %v, want nil", err)
+				}
+				return
+			}
+			var got Errors
+			if !errors.As(err, &got) {
+				t.Fatalf("Struct = %v, want Errors", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct = %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStructRejectsNonStruct(t *testing.T) {
+	err := Struct("not a struct")
+	var fieldErrs Errors
+	if err == nil || errors.As(err, &fieldErrs) {
+		t.Errorf("Struct(string) = %v, want a non-validation error", err)
+	}
+}
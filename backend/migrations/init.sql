@@ -5,15 +5,23 @@ CREATE TABLE IF NOT EXISTS blog_posts (
     title VARCHAR(255) NOT NULL,
     slug VARCHAR(255) UNIQUE NOT NULL,
     content TEXT NOT NULL,
+    content_format VARCHAR(20) NOT NULL DEFAULT 'html',
+    content_html TEXT,
     excerpt VARCHAR(500),
+    word_count INTEGER NOT NULL DEFAULT 0,
+    reading_time_minutes INTEGER NOT NULL DEFAULT 0,
+    table_of_contents TEXT,
     author_name VARCHAR(100) NOT NULL,
     tags VARCHAR(500),
     category VARCHAR(100),
     featured BOOLEAN DEFAULT FALSE,
-    published BOOLEAN DEFAULT TRUE,
+    published BOOLEAN DEFAULT FALSE,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
     published_at TIMESTAMP,
+    version INTEGER NOT NULL DEFAULT 1,
     created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
 );
 
 -- Create indexes for better performance
@@ -22,13 +30,153 @@ CREATE INDEX IF NOT EXISTS idx_blog_posts_published ON blog_posts(published);
 CREATE INDEX IF NOT EXISTS idx_blog_posts_published_at ON blog_posts(published_at);
 CREATE INDEX IF NOT EXISTS idx_blog_posts_category ON blog_posts(category);
 CREATE INDEX IF NOT EXISTS idx_blog_posts_featured ON blog_posts(featured);
+CREATE INDEX IF NOT EXISTS idx_blog_posts_status ON blog_posts(status);
+CREATE INDEX IF NOT EXISTS idx_blog_posts_deleted_at ON blog_posts(deleted_at);
 
 -- Full text search index
 CREATE INDEX IF NOT EXISTS idx_blog_posts_search 
 ON blog_posts USING gin(to_tsvector('english', title || ' ' || content || ' ' || excerpt || ' ' || COALESCE(tags, '')));
 
+-- Create users table
+CREATE TABLE IF NOT EXISTS users (
+    id SERIAL PRIMARY KEY,
+    email VARCHAR(255) UNIQUE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    role VARCHAR(20) NOT NULL DEFAULT 'reader' CHECK (role IN ('admin', 'editor', 'author', 'reader')),
+    password_hash VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Create refresh_tokens table (only SHA-256 hashes of the tokens are stored)
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens(user_id);
+
+-- Link posts to their authors; author_name stays as the display name
+ALTER TABLE blog_posts ADD COLUMN IF NOT EXISTS author_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_blog_posts_author_id ON blog_posts(author_id);
+
+-- Slugs a post used to have; GET /api/posts/{old-slug} redirects to the current one
+CREATE TABLE IF NOT EXISTS slug_history (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    slug VARCHAR(255) UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_slug_history_post_id ON slug_history(post_id);
+
+-- Tags and the posts using them. blog_posts.tags is kept as a comma-separated
+-- copy for full-text search; the server fills these tables from it on startup.
+CREATE TABLE IF NOT EXISTS tags (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(100) UNIQUE NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS post_tags (
+    post_id INTEGER NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (post_id, tag_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_tags_tag_id ON post_tags(tag_id);
+
+-- Categories nest through parent_id. The server moves names from the old
+-- free-text blog_posts.category column into this table on startup.
+CREATE TABLE IF NOT EXISTS categories (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT,
+    parent_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories(parent_id);
+
+ALTER TABLE blog_posts ADD COLUMN IF NOT EXISTS category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_blog_posts_category_id ON blog_posts(category_id);
+
+-- Snapshots of every saved version of a post, numbered by blog_posts.version
+CREATE TABLE IF NOT EXISTS post_revisions (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    content TEXT NOT NULL,
+    content_format VARCHAR(20) NOT NULL DEFAULT 'html',
+    tags VARCHAR(500),
+    editor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    editor_name VARCHAR(100),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_revisions_post_version ON post_revisions(post_id, version);
+CREATE INDEX IF NOT EXISTS idx_post_revisions_editor_id ON post_revisions(editor_id);
+
+-- Review requests on posts and their threaded discussion
+CREATE TABLE IF NOT EXISTS reviews (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    requested_by_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    reviewer_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    state VARCHAR(20) NOT NULL DEFAULT 'pending',
+    post_version INTEGER NOT NULL,
+    decided_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_post_id ON reviews(post_id);
+CREATE INDEX IF NOT EXISTS idx_reviews_requested_by_id ON reviews(requested_by_id);
+CREATE INDEX IF NOT EXISTS idx_reviews_reviewer_id ON reviews(reviewer_id);
+CREATE INDEX IF NOT EXISTS idx_reviews_state ON reviews(state);
+
+CREATE TABLE IF NOT EXISTS review_comments (
+    id SERIAL PRIMARY KEY,
+    review_id INTEGER NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES review_comments(id) ON DELETE CASCADE,
+    author_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    author_name VARCHAR(100) NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_comments_review_id ON review_comments(review_id);
+CREATE INDEX IF NOT EXISTS idx_review_comments_parent_id ON review_comments(parent_id);
+CREATE INDEX IF NOT EXISTS idx_review_comments_author_id ON review_comments(author_id);
+
+-- Reader comments, held for moderation until approved
+CREATE TABLE IF NOT EXISTS comments (
+    id SERIAL PRIMARY KEY,
+    post_id INTEGER NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    parent_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+    author_name VARCHAR(100) NOT NULL,
+    author_email VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    moderated_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments(post_id);
+CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments(parent_id);
+CREATE INDEX IF NOT EXISTS idx_comments_status ON comments(status);
+
 -- Insert sample data
-INSERT INTO blog_posts (title, slug, content, excerpt, author_name, tags, category, featured, published, published_at) VALUES
+INSERT INTO blog_posts (title, slug, content, excerpt, author_name, tags, category, featured, published, status, published_at) VALUES
 (
     'Understanding Compensatory Damages in an ADA Context',
     'understanding-compensatory-damages-ada-context-1234',
@@ -39,6 +187,7 @@ INSERT INTO blog_posts (title, slug, content, excerpt, author_name, tags, catego
     'Legal',
     true,
     true,
+    'published',
     '2024-01-15 10:00:00'
 ),
 (
@@ -51,6 +200,7 @@ INSERT INTO blog_posts (title, slug, content, excerpt, author_name, tags, catego
     'Technology',
     true,
     true,
+    'published',
     '2024-01-20 14:30:00'
 ),
 (
@@ -63,6 +213,7 @@ INSERT INTO blog_posts (title, slug, content, excerpt, author_name, tags, catego
     'Business',
     false,
     true,
+    'published',
     '2024-01-25 09:15:00'
 );
 
@@ -352,10 +352,11 @@
                         vm.isLoading = true;
                         vm.error = null;
 
-                        BlogService.getPosts(1, 20, vm.searchQuery)
+                        BlogService.searchPosts(vm.searchQuery, 1, 20)
                             .then(function(response) {
                                 vm.results = response.posts || vm.searchMockPosts(vm.searchQuery);
-                                vm.totalResults = vm.results.length;
+                                vm.totalResults = response.total_posts || vm.results.length;
+                                vm.facets = response.facets;
                                 vm.isLoading = false;
                             })
                             .catch(function(error) {
@@ -448,11 +449,30 @@
                         });
                 };
 
+                // Search posts; the response also carries facet counts for
+                // category, tag, author and year
+                service.searchPosts = function(query, page, limit, filters) {
+                    var params = angular.extend({
+                        q: query,
+                        page: page || 1,
+                        limit: limit || 6
+                    }, filters);
+
+                    return $http.get(API_BASE + '/search', { params: params })
+                        .then(function(response) {
+                            return response.data;
+                        })
+                        .catch(function(error) {
+                            console.error('Error searching posts:', error);
+                            return $q.reject(error);
+                        });
+                };
+
                 // Get single post by slug
                 service.getPostBySlug = function(slug) {
                     return $http.get(API_BASE + '/posts/' + slug)
                         .then(function(response) {
-                            return response.data;
+                            return response.data.post;
                         })
                         .catch(function(error) {
                             console.error('Error fetching post:', error);
@@ -464,7 +484,7 @@
                 service.createPost = function(postData) {
                     return $http.post(API_BASE + '/posts', postData)
                         .then(function(response) {
-                            return response.data;
+                            return response.data.post;
                         })
                         .catch(function(error) {
                             console.error('Error creating post:', error);
@@ -78,9 +78,10 @@
                     vm.error = null;
                     vm.hasSearched = true;
 
-                    BlogService.getPosts(vm.currentPage, vm.postsPerPage, vm.searchQuery.trim())
+                    BlogService.searchPosts(vm.searchQuery.trim(), vm.currentPage, vm.postsPerPage)
                         .then(function(data) {
                             vm.posts = data.posts || [];
+                            vm.facets = data.facets;
                             vm.currentPage = data.current_page || 1;
                             vm.totalPages = data.total_pages || 1;
                             vm.totalPosts = data.total_posts || 0;
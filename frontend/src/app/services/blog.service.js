@@ -129,15 +129,35 @@
                 return deferred.promise;
             };
 
+            // Search posts with optional category, tag, author and year filters
+            service.searchPosts = function(query, page, limit, filters) {
+                page = page || 1;
+                limit = limit || 6;
+
+                var params = angular.extend({
+                    q: query,
+                    page: page,
+                    limit: limit
+                }, filters);
+
+                return $http.get(API_BASE + '/search', { params: params })
+                    .then(function(response) {
+                        return response.data;
+                    })
+                    .catch(function(error) {
+                        console.log('API not available, using sample data');
+                        var filteredPosts = query ? filterPosts(samplePosts, query) : samplePosts;
+                        return paginatePosts(filteredPosts, page, limit);
+                    });
+            };
+
             // Get single post by slug
             service.getPostBySlug = function(slug) {
                 var deferred = $q.defer();
                 
                 $http.get(API_BASE + '/posts/' + slug)
                     .then(function(response) {
-                        var post = response.data;
-                        post.tags = processTags(post.tags);
-                        deferred.resolve(post);
+                        deferred.resolve(response.data.post);
                     })
                     .catch(function(error) {
                         console.log('API not available, using sample data');
@@ -179,20 +199,12 @@
                 return {
                     posts: paginatedPosts,
                     current_page: page,
+                    limit: limit,
                     total_pages: Math.ceil(posts.length / limit),
                     total_posts: posts.length,
                     has_next: endIndex < posts.length,
                     has_prev: page > 1
                 };
             }
-
-            function processTags(tags) {
-                if (typeof tags === 'string' && tags) {
-                    return tags.split(',').map(function(tag) {
-                        return tag.trim();
-                    });
-                }
-                return tags || [];
-            }
         }]);
 })();
\ No newline at end of file